@@ -0,0 +1,199 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/brogergvhs/mangad/internal/chapters"
+	"github.com/brogergvhs/mangad/internal/config"
+	"github.com/brogergvhs/mangad/internal/providers"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	flagListURL      string
+	flagListFormat   string
+	flagListOutput   string
+	flagListChapters string
+	flagListRange    string
+	flagListList     string
+)
+
+func init() {
+	chaptersCmd := &cobra.Command{
+		Use:   "chapters",
+		Short: "List the chapters of a series as a table, JSON or CSV",
+		RunE:  runChapters,
+	}
+
+	chaptersCmd.Flags().StringVar(&flagListURL, "url", "", "manga series/chapters page URL")
+	chaptersCmd.Flags().StringVar(&flagListFormat, "format", "table", "output format: table, json or csv")
+	chaptersCmd.Flags().StringVar(&flagListOutput, "output", "", "output folder checked for already downloaded chapters")
+	chaptersCmd.Flags().StringVar(&flagListChapters, "chapters", "", "only list chapters matching a label expression (e.g. \"10-20,extras\")")
+	chaptersCmd.Flags().StringVar(&flagListRange, "range", "", "only list a range of chapters by index (e.g. 5-12)")
+	chaptersCmd.Flags().StringVar(&flagListList, "list", "", "only list specific chapter indices (e.g. 1,3,5)")
+
+	rootCmd.AddCommand(chaptersCmd)
+}
+
+// chapterRow is one line of the chapters output. Index is the 1-based
+// position in the full list, as used by --range and --list.
+type chapterRow struct {
+	Index  int                   `json:"index"`
+	Label  string                `json:"label"`
+	Number float64               `json:"number"`
+	Volume int                   `json:"volume,omitempty"`
+	Kind   providers.ChapterKind `json:"kind"`
+	Title  string                `json:"title"`
+	URL    string                `json:"url"`
+	Date   string                `json:"date,omitempty"`
+	Status string                `json:"status"` // downloaded or missing
+}
+
+func runChapters(_ *cobra.Command, _ []string) error {
+	switch flagListFormat {
+	case "table", "json", "csv":
+	default:
+		return fmt.Errorf("unknown format %q (use table, json or csv)", flagListFormat)
+	}
+
+	cfg, _, err := config.LoadMerged(config.Options{
+		IgnoreConfig: flagIgnoreConfig,
+		Debug:        flagDebug,
+		DefaultURL:   flagListURL,
+		Output:       flagListOutput,
+	})
+	if err != nil {
+		return err
+	}
+	if cfg.DefaultURL == "" {
+		return fmt.Errorf("missing --url and no default_url in config")
+	}
+
+	logSvc, err := newLogger(cfg)
+	if err != nil {
+		return err
+	}
+
+	_, scr, ctx, err := setupEnvironment(cfg, logSvc)
+	if err != nil {
+		return err
+	}
+
+	list, err := loadChapterList(ctx, scr, cfg)
+	if err != nil {
+		return err
+	}
+	all := list.all
+
+	selected := all
+	switch {
+	case flagListChapters != "":
+		sel, err := chapters.ParseSelection(flagListChapters)
+		if err != nil {
+			return err
+		}
+		selected = sel.Apply(all)
+	case flagListRange != "" || flagListList != "":
+		if selected, err = chapters.Filter(all, "", flagListRange, "", flagListList, ""); err != nil {
+			return err
+		}
+	}
+
+	index := make(map[string]int, len(all))
+	for i, ch := range all {
+		index[ch.URL] = i + 1
+	}
+
+	have := list.downloaded(cfg)
+	rows := make([]chapterRow, 0, len(selected))
+	for _, ch := range selected {
+		row := chapterRow{
+			Index:  index[ch.URL],
+			Label:  ch.Label,
+			Number: ch.Number,
+			Volume: ch.Volume,
+			Kind:   ch.Kind,
+			Title:  ch.Title,
+			URL:    ch.URL,
+			Status: "missing",
+		}
+		if !ch.Date.IsZero() {
+			row.Date = ch.Date.Format("2006-01-02")
+		}
+		if have(ch) {
+			row.Status = "downloaded"
+		}
+		rows = append(rows, row)
+	}
+
+	switch flagListFormat {
+	case "json":
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(rows)
+	case "csv":
+		return writeChaptersCSV(rows)
+	}
+
+	printChaptersTable(rows)
+	if len(list.anomalies) > 0 {
+		fmt.Println()
+		printAnomalies(list.anomalies)
+	}
+	if len(list.collisions) > 0 {
+		fmt.Println()
+		printCollisions(list.collisions)
+	}
+
+	return nil
+}
+
+func printChaptersTable(rows []chapterRow) {
+	w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "INDEX\tLABEL\tNUMBER\tVOLUME\tKIND\tTITLE\tDATE\tSTATUS\tURL")
+	for _, r := range rows {
+		vol := "-"
+		if r.Volume > 0 {
+			vol = strconv.Itoa(r.Volume)
+		}
+		date := r.Date
+		if date == "" {
+			date = "-"
+		}
+		_, _ = fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			r.Index, r.Label, providers.FormatNumber(r.Number), vol, r.Kind, truncate(r.Title, 40), date, r.Status, r.URL)
+	}
+	if err := w.Flush(); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: failed to flush table output: %v\n", err)
+	}
+}
+
+func writeChaptersCSV(rows []chapterRow) error {
+	w := csv.NewWriter(stdout)
+	if err := w.Write([]string{"index", "label", "number", "volume", "kind", "title", "date", "status", "url"}); err != nil {
+		return err
+	}
+
+	for _, r := range rows {
+		vol := ""
+		if r.Volume > 0 {
+			vol = strconv.Itoa(r.Volume)
+		}
+		rec := []string{
+			strconv.Itoa(r.Index), r.Label, providers.FormatNumber(r.Number), vol,
+			string(r.Kind), r.Title, r.Date, r.Status, r.URL,
+		}
+		if err := w.Write(rec); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+
+	return w.Error()
+}
@@ -2,19 +2,28 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/brogergvhs/mangad/internal/chapters"
 	"github.com/brogergvhs/mangad/internal/config"
 	"github.com/brogergvhs/mangad/internal/downloader"
+	"github.com/brogergvhs/mangad/internal/fetcher"
+	"github.com/brogergvhs/mangad/internal/imgfmt"
+	"github.com/brogergvhs/mangad/internal/providers"
 	"github.com/brogergvhs/mangad/internal/providers/generic"
+	"github.com/brogergvhs/mangad/internal/report"
+	"github.com/brogergvhs/mangad/internal/transcode"
 	"github.com/brogergvhs/mangad/internal/ui"
 	"github.com/brogergvhs/mangad/internal/util"
 
@@ -26,11 +35,23 @@ var (
 	// selection
 	flagURL          string
 	flagChapter      string
+	flagChapters     string
+	flagSince        string
+	flagLatest       int
+	flagNewOnly      bool
+	flagInteractive  bool
+	flagRetryFailed  bool
+	flagQueue        string
+	flagEvents       string
+	flagEventsFile   string
 	flagRange        string
 	flagExcludeRange string
 	flagList         string
 	flagExcludeList  string
 	flagAllowExt     string
+	flagPreferGroup  string
+	flagPreferLang   string
+	flagBlock        []string
 
 	// runtime
 	flagOutput         string
@@ -41,6 +62,17 @@ var (
 	flagSkipBroken     bool
 	flagCheckJS        bool
 	flagWithCF         bool
+	flagProbeImages    bool
+	flagFetcher        string
+	flagForce          bool
+	flagNameTemplate   string
+	flagFilenamePolicy string
+	flagLayout         string
+	flagSeriesName     string
+	flagConvertWebP    string
+	flagConvertPNG     string
+	flagJPEGQuality    int
+	flagStripMetadata  bool
 
 	// headers/auth
 	flagCookie     string
@@ -58,21 +90,44 @@ func init() {
 	// selection
 	downloadCmd.Flags().StringVar(&flagURL, "url", "", "manga series/chapters page URL")
 	downloadCmd.Flags().StringVar(&flagChapter, "chapter", "", "download single chapter by index or label (e.g. 5 or 28.5)")
+	downloadCmd.Flags().StringVar(&flagChapters, "chapters", "", "select chapters by label, e.g. \"10-20.5,25,!13,vol:3,extras,latest:5\"")
+	downloadCmd.Flags().StringVar(&flagSince, "since", "", "only chapters released since a date or age (e.g. 2024-01-15, 14d, 2w)")
+	downloadCmd.Flags().IntVar(&flagLatest, "latest", 0, "only the N most recently released chapters")
+	downloadCmd.Flags().BoolVar(&flagNewOnly, "new-only", false, "only chapters newer than the newest one already in the output folder")
+	downloadCmd.Flags().StringVar(&flagEvents, "events", "", "emit machine-readable progress events (json: one JSON object per line)")
+	downloadCmd.Flags().StringVar(&flagEventsFile, "events-file", "", "write --events to this file instead of stdout")
+	downloadCmd.Flags().StringVar(&flagQueue, "queue", "", "download every series listed in a queue YAML file")
+	downloadCmd.Flags().BoolVar(&flagInteractive, "interactive", false, "pick chapters from a searchable list")
+	downloadCmd.Flags().BoolVar(&flagRetryFailed, "retry-failed", false, "download exactly the chapters listed in the failures file of the output folder")
 	downloadCmd.Flags().StringVar(&flagRange, "range", "", "download range of chapters by index (e.g. 5-12)")
 	downloadCmd.Flags().StringVar(&flagExcludeRange, "exclude-range", "", "exclude range of chapters by index (e.g. 5-12)")
 	downloadCmd.Flags().StringVar(&flagList, "list", "", "download specific chapter indices (e.g. 1,3,5)")
 	downloadCmd.Flags().StringVar(&flagExcludeList, "exclude-list", "", "exclude specific chapter indices (e.g. 1,3,5)")
 	downloadCmd.Flags().StringVar(&flagAllowExt, "allow-ext", "", "Allowed image extensions (e.g. \"webp|jpg|png\")")
+	downloadCmd.Flags().StringVar(&flagPreferGroup, "prefer-group", "", "preferred scanlation groups for duplicate chapters, in order (e.g. \"GroupA|GroupB\")")
+	downloadCmd.Flags().StringArrayVar(&flagBlock, "block", nil, "regex for image URLs to never download (ads, credits); repeatable")
+	downloadCmd.Flags().StringVar(&flagPreferLang, "prefer-lang", "", "preferred languages for duplicate chapters, in order (e.g. \"en|es\")")
 
 	// runtime
 	downloadCmd.Flags().StringVar(&flagOutput, "output", "", "output folder for CBZ files")
 	downloadCmd.Flags().IntVar(&flagImageWorkers, "image-workers", 5, "parallel image downloads per chapter")
 	downloadCmd.Flags().IntVar(&flagChapterWorkers, "chapter-workers", 2, "parallel chapter downloads")
-	downloadCmd.Flags().BoolVar(&flagKeepFolders, "keep-folders", false, "keep temporary folders")
+	downloadCmd.Flags().StringVar(&flagNameTemplate, "name-template", "", "template for CBZ paths, e.g. \"{{.Series}}/{{.Series}} - Ch. {{.Padded}}\"")
+	downloadCmd.Flags().StringVar(&flagFilenamePolicy, "filename-policy", "", "characters allowed in file names: posix, windows (default), fat32, ascii or slug")
+	downloadCmd.Flags().StringVar(&flagLayout, "layout", "", "flat (default) or library: one folder per series with series.json and cover.jpg")
+	downloadCmd.Flags().StringVar(&flagSeriesName, "series-name", "", "series name for folders and templates instead of the one found on the page")
+	downloadCmd.Flags().StringVar(&flagConvertWebP, "convert-webp", "", "convert WebP pages to jpeg or png")
+	downloadCmd.Flags().StringVar(&flagConvertPNG, "convert-png", "", "convert PNG pages to jpeg")
+	downloadCmd.Flags().IntVar(&flagJPEGQuality, "jpeg-quality", 0, "quality of converted JPEG pages, 1-100 (default 85)")
+	downloadCmd.Flags().BoolVar(&flagStripMetadata, "strip-metadata", false, "drop EXIF, XMP and text metadata from pages")
+	downloadCmd.Flags().BoolVar(&flagKeepFolders, "keep-folders", false, "keep temporary folders (and the original pages when converting)")
 	downloadCmd.Flags().BoolVar(&flagDryRun, "dry-run", false, "show what would be downloaded, don’t download")
 	downloadCmd.Flags().BoolVar(&flagSkipBroken, "skip-broken", false, "skip failed images instead of failing the whole chapter")
 	downloadCmd.Flags().BoolVar(&flagCheckJS, "check-js", false, "Enable generic JS scanning & dynamic AJAX endpoint discovery")
-	downloadCmd.Flags().BoolVar(&flagWithCF, "with-cf", false, "Allow using embedded Selenium fallback when Cloudflare blocks requests. Requires a working 'python3' executable with SeleniumBase installed.")
+	downloadCmd.Flags().BoolVar(&flagProbeImages, "probe-images", false, "probe image headers and drop icons and banner-shaped images")
+	downloadCmd.Flags().BoolVar(&flagWithCF, "with-cf", false, "Allow using the browser fallback when Cloudflare blocks requests. The default embedded Selenium fetcher requires a working 'python3' executable with SeleniumBase installed.")
+	downloadCmd.Flags().BoolVar(&flagForce, "force", false, "download even when the chapter list check finds severe anomalies")
+	downloadCmd.Flags().StringVar(&flagFetcher, "fetcher", "", "external fetcher command used by --with-cf instead of the embedded Selenium script (e.g. \"node fetch.js\"; quote arguments that contain spaces)")
 
 	// headers/auth
 	downloadCmd.Flags().StringVar(&flagCookie, "cookie", "", "cookie string, e.g. \"key=value; other=123\"")
@@ -83,38 +138,128 @@ func init() {
 }
 
 func runDownload(cmd *cobra.Command, _ []string) error {
-	cfg, logSvc, err := prepareConfigAndLogger(cmd)
+	closeEvents, err := setupEvents()
 	if err != nil {
 		return err
 	}
+	defer closeEvents()
 
-	client, scr, ctx, err := setupEnvironment(cfg, logSvc)
+	if flagRetryFailed && (flagChapter != "" || flagChapters != "" || flagRange != "" || flagList != "" ||
+		flagExcludeRange != "" || flagExcludeList != "" || flagInteractive) {
+		return fmt.Errorf("--retry-failed can't be combined with chapter selection or --interactive")
+	}
+
+	if flagQueue != "" {
+		return runQueue(cmd)
+	}
+
+	cfg, usedPath, err := loadDownloadConfig(cmd)
 	if err != nil {
 		return err
 	}
 
-	allChapters, err := fetchAllChapters(ctx, scr, cfg)
+	return downloadSeries(cmd, cfg, usedPath)
+}
+
+// downloadSeries downloads the chapters of cfg.DefaultURL that the
+// selection flags pick. search calls it with a config for the chosen series.
+func downloadSeries(cmd *cobra.Command, cfg *config.Config, usedPath string) error {
+	logSvc, err := prepareDownload(cfg, usedPath)
 	if err != nil {
 		return err
 	}
 
-	selected, err := selectChapters(allChapters, cfg)
+	client, scr, ctx, err := setupEnvironment(cfg, logSvc)
+	if err != nil {
+		return err
+	}
+
+	list, err := fetchAllChapters(ctx, scr, cfg)
 	if err != nil {
 		return err
 	}
 
+	var selected []chapters.Chapter
+	if flagRetryFailed {
+		if selected, err = retrySelection(list.all, cfg); err != nil {
+			return err
+		}
+		if len(selected) == 0 {
+			fmt.Printf("No failed chapters to retry in %s\n", report.Path(cfg.Output))
+			return nil
+		}
+	} else if flagInteractive {
+		selected, err = pickChapters(list.all, list.downloaded(cfg))
+		if err != nil {
+			return err
+		}
+		if err := offerSaveSelection(list.all, selected); err != nil {
+			return err
+		}
+	} else {
+		var byIndex bool
+		selected, byIndex, err = selectChapters(list.all, cfg)
+		if err != nil {
+			return err
+		}
+		// a broken list only picks the wrong chapters when they are picked
+		// by position
+		if byIndex && !flagDryRun && !flagForce && chapters.HasSevere(list.anomalies) {
+			return fmt.Errorf("chapter list check found severe anomalies; review them with --dry-run, select chapters by number with --chapter or `chapters`, or re-run with --force")
+		}
+
+		selected, err = filterRecent(selected, list, cfg, recentFlags())
+		if err != nil {
+			return err
+		}
+	}
+
 	if len(selected) == 0 {
+		if flagNewOnly {
+			return nil
+		}
 		return fmt.Errorf("no chapters selected")
 	}
 
 	if flagDryRun {
-		return doDryRun(ctx, scr, selected)
+		return doDryRun(ctx, scr, cfg, selected, list)
 	}
 
-	return performDownloads(ctx, scr, client, cfg, logSvc, selected)
+	// failed chapters are reported in the summary; usage would only bury it
+	cmd.SilenceUsage = true
+	return performDownloads(ctx, scr, client, cfg, logSvc, list, selected)
 }
 
-func prepareConfigAndLogger(cmd *cobra.Command) (*config.Config, *ui.Logger, error) {
+// prepareDownload sets up the logger and the output folder for cfg and
+// prints the config in use.
+func prepareDownload(cfg *config.Config, usedPath string) (*ui.Logger, error) {
+	logSvc, err := newLogger(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if usedPath != "" {
+		fmt.Printf("Config file: %s\n", usedPath)
+	}
+
+	if err := os.MkdirAll(cfg.Output, 0755); err != nil {
+		return nil, fmt.Errorf("cannot create output folder: %w", err)
+	}
+
+	fmt.Println("Full config:")
+	cfg.Print()
+	fmt.Println()
+
+	if cfg.DefaultURL == "" {
+		return nil, fmt.Errorf("missing --url and no default_url in config")
+	}
+
+	return logSvc, nil
+}
+
+// loadDownloadConfig loads the active config with the download flags
+// applied on top.
+func loadDownloadConfig(cmd *cobra.Command) (*config.Config, string, error) {
 	cfg, usedPath, err := config.LoadMerged(config.Options{
 		IgnoreConfig:        flagIgnoreConfig,
 		Debug:               flagDebug,
@@ -123,6 +268,7 @@ func prepareConfigAndLogger(cmd *cobra.Command) (*config.Config, *ui.Logger, err
 		ChapterWorkers:      0,
 		KeepFolders:         flagKeepFolders,
 		DefaultURL:          flagURL,
+		Chapters:            flagChapters,
 		DefaultRange:        flagRange,
 		DefaultExcludeRange: flagExcludeRange,
 		DefaultList:         flagList,
@@ -133,9 +279,18 @@ func prepareConfigAndLogger(cmd *cobra.Command) (*config.Config, *ui.Logger, err
 		CookieFile:          flagCookieFile,
 		UserAgent:           flagUserAgent,
 		SkipBroken:          flagSkipBroken,
+		ProbeImages:         flagProbeImages,
+		NameTemplate:        flagNameTemplate,
+		FilenamePolicy:      flagFilenamePolicy,
+		Layout:              flagLayout,
+		SeriesName:          flagSeriesName,
+		ConvertWebP:         flagConvertWebP,
+		ConvertPNG:          flagConvertPNG,
+		JPEGQuality:         flagJPEGQuality,
+		StripMetadata:       flagStripMetadata,
 	})
 	if err != nil {
-		return nil, nil, err
+		return nil, "", err
 	}
 
 	if cmd.Flags().Changed("image-workers") {
@@ -147,32 +302,38 @@ func prepareConfigAndLogger(cmd *cobra.Command) (*config.Config, *ui.Logger, err
 	if flagAllowExt != "" {
 		cfg.AllowExt = splitExt(flagAllowExt)
 	}
-
-	logSvc := ui.NewLogger(cfg.Debug)
-
-	if usedPath != "" {
-		fmt.Printf("Config file: %s\n", usedPath)
+	if flagFetcher != "" {
+		if cfg.FetcherCommand, err = fetcher.SplitCommand(flagFetcher); err != nil {
+			return nil, "", fmt.Errorf("--fetcher: %w", err)
+		}
+	}
+	if len(flagBlock) > 0 {
+		cfg.BlockPatterns = append(cfg.BlockPatterns, flagBlock...)
+	}
+	if flagPreferGroup != "" {
+		cfg.PreferredGroups = splitList(flagPreferGroup)
+	}
+	if flagPreferLang != "" {
+		cfg.PreferredLanguages = splitExt(flagPreferLang)
 	}
-
 	if cfg.Output == "" {
 		cfg.Output = "."
 	}
-	if err := os.MkdirAll(cfg.Output, 0755); err != nil {
-		return nil, nil, fmt.Errorf("cannot create output folder: %w", err)
-	}
-
-	fmt.Println("Full config:")
-	cfg.Print()
-	fmt.Println()
-
-	if cfg.DefaultURL == "" {
-		return nil, nil, fmt.Errorf("missing --url and no default_url in config")
+	if flagRetryFailed && flagURL == "" {
+		// retry the series in the failures file, not the profile's default
+		if f, err := report.Load(cfg.Output); err == nil && f.OnlySeries() != "" {
+			cfg.DefaultURL = f.OnlySeries()
+		}
 	}
 
-	return cfg, logSvc, nil
+	return cfg, usedPath, nil
 }
 
 func setupEnvironment(cfg *config.Config, logSvc *ui.Logger) (*http.Client, *generic.Scraper, context.Context, error) {
+	if _, err := transcodeOptions(cfg); err != nil {
+		return nil, nil, nil, err
+	}
+
 	client, err := util.NewHTTPClient(util.HTTPClientOptions{
 		Timeout:     30 * time.Second,
 		UserAgent:   util.PickUserAgent(cfg.UserAgent),
@@ -187,31 +348,194 @@ func setupEnvironment(cfg *config.Config, logSvc *ui.Logger) (*http.Client, *gen
 
 	ctx := context.Background()
 	util.SetupInterruptHandler(cfg.Output)
-	scr := generic.NewScraper(client, logSvc, cfg.AllowExt, cfg.CheckJS, cfg.WithCF)
+	scr, err := generic.NewScraper(client, logSvc, generic.ScraperOptions{
+		AllowExt:      cfg.AllowExt,
+		CheckJS:       cfg.CheckJS,
+		WithCF:        cfg.WithCF,
+		BlockPatterns: cfg.BlockPatterns,
+		ProbeImages:   cfg.ProbeImages,
+		Fetcher:       fetcher.New(cfg.FetcherCommand),
+		Fetch: generic.FetchOptions{
+			UserAgent:    cfg.UserAgent,
+			Proxy:        cfg.FetcherProxy,
+			WaitSelector: cfg.FetcherWaitSelector,
+			WaitSeconds:  cfg.FetcherWaitSeconds,
+		},
+	})
+	if err != nil {
+		return nil, nil, nil, err
+	}
 
 	return client, scr, ctx, nil
 }
 
-func fetchAllChapters(ctx context.Context, scr *generic.Scraper, cfg *config.Config) ([]chapters.Chapter, error) {
-	allChaptersRaw, err := scr.GetChapters(ctx, cfg.DefaultURL)
+// transcodeOptions reads the page conversion settings of the profile.
+func transcodeOptions(cfg *config.Config) (transcode.Options, error) {
+	return transcode.NewOptions(cfg.ConvertWebP, cfg.ConvertPNG, cfg.JPEGQuality, cfg.StripMetadata)
+}
+
+// chapterList is the fetched chapter list after duplicate resolution,
+// together with what was collapsed and what looked wrong.
+type chapterList struct {
+	all        []chapters.Chapter
+	resolved   []chapters.Resolution
+	anomalies  []chapters.Anomaly
+	collisions []chapters.Collision
+	series     providers.Series
+	names      *chapters.Namer
+}
+
+// cbzPath is where the chapter's CBZ is written.
+func (l chapterList) cbzPath(cfg *config.Config, ch chapters.Chapter) string {
+	return filepath.Join(cfg.Output, l.names.Path(ch))
+}
+
+// downloaded reports whether the chapter's CBZ already exists, under its
+// current name or the old lowercase_underscore default name.
+func (l chapterList) downloaded(cfg *config.Config) func(chapters.Chapter) bool {
+	return func(ch chapters.Chapter) bool {
+		for _, p := range []string{l.cbzPath(cfg, ch), ch.OutputCBZPath(cfg.Output)} {
+			if _, err := os.Stat(p); err == nil {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// reSeriesFolder matches a name template that already starts with the
+// series folder, so library layout doesn't nest it twice.
+var reSeriesFolder = regexp.MustCompile(`^\s*\{\{-?\s*\.Series\s*-?\}\}/`)
+
+// newNamer names all chapters of series with the profile's name template
+// and layout. It also returns the names that several chapters would have
+// shared.
+func newNamer(cfg *config.Config, series string, all []chapters.Chapter) (*chapters.Namer, []chapters.Collision, error) {
+	policy, err := chapters.ParsePolicy(cfg.FilenamePolicy)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+
+	tmpl := cfg.NameTemplate
+	switch cfg.Layout {
+	case "", layoutFlat:
+	case layoutLibrary:
+		if tmpl == "" {
+			tmpl = chapters.LibraryTemplate
+		}
+		if !reSeriesFolder.MatchString(tmpl) {
+			tmpl = "{{.Series}}/" + tmpl
+		}
+	default:
+		return nil, nil, fmt.Errorf("unknown layout %q (use flat or library)", cfg.Layout)
+	}
+
+	names, err := chapters.NewNamer(tmpl, series, policy)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	allChapters := make([]chapters.Chapter, len(allChaptersRaw))
-	for i, c := range allChaptersRaw {
-		allChapters[i] = chapters.Chapter{Chapter: c}
+	return names, names.Plan(all), nil
+}
+
+func printCollisions(list []chapters.Collision) {
+	for _, c := range list {
+		ui.Printf("Note: chapters %s would all be saved as %s; renamed to %s.\n",
+			strings.Join(c.Chapters, ", "), c.Path, strings.Join(c.Renamed, ", "))
+	}
+	if len(list) > 0 {
+		ui.Println()
+	}
+}
+
+// seriesTitle turns the last part of a series URL into a title
+// ("/manga/one-piece/" gives "One Piece").
+func seriesTitle(raw string) string {
+	name := seriesName(raw)
+	name = strings.NewReplacer("-", " ", "_", " ").Replace(strings.TrimSuffix(name, filepath.Ext(name)))
+	words := strings.Fields(name)
+	for i, w := range words {
+		r, size := utf8.DecodeRuneInString(w)
+		words[i] = string(unicode.ToUpper(r)) + w[size:]
+	}
+
+	return strings.Join(words, " ")
+}
+
+// loadChapterList fetches the chapter list of cfg.DefaultURL, checks it,
+// collapses duplicates and names every chapter, without printing anything.
+func loadChapterList(ctx context.Context, scr *generic.Scraper, cfg *config.Config) (chapterList, error) {
+	raw, err := scr.GetChapters(ctx, cfg.DefaultURL)
+	if err != nil {
+		return chapterList{}, err
+	}
+
+	all := make([]chapters.Chapter, len(raw))
+	for i, c := range raw {
+		all[i] = chapters.Chapter{Chapter: c}
+	}
+
+	anomalies := chapters.Analyze(all)
+
+	all, resolved := chapters.ResolveDuplicates(all, chapters.Preferences{
+		Groups:    cfg.PreferredGroups,
+		Languages: cfg.PreferredLanguages,
+	})
+
+	series := loadSeries(ctx, scr, cfg)
+	names, collisions, err := newNamer(cfg, seriesNameFor(cfg, series), all)
+	if err != nil {
+		return chapterList{}, err
+	}
+
+	return chapterList{
+		all:        all,
+		resolved:   resolved,
+		anomalies:  anomalies,
+		collisions: collisions,
+		series:     series,
+		names:      names,
+	}, nil
+}
+
+// fetchAllChapters is loadChapterList for downloads: it also reports what
+// was found, the duplicates, anomalies and name collisions.
+func fetchAllChapters(ctx context.Context, scr *generic.Scraper, cfg *config.Config) (chapterList, error) {
+	list, err := loadChapterList(ctx, scr, cfg)
+	if err != nil {
+		return chapterList{}, err
 	}
 
 	if flagChapter == "" && flagRange == "" && flagList == "" &&
-		cfg.DefaultRange == "" && cfg.DefaultList == "" {
-		fmt.Printf("Found %d chapters on the site.\n\n", len(allChapters))
+		cfg.Chapters == "" && cfg.DefaultRange == "" && cfg.DefaultList == "" {
+		ui.Printf("Found %d chapters on the site.\n\n", len(list.all))
+	}
+	if len(list.resolved) > 0 {
+		ui.Printf("Resolved %d duplicate chapters (use --dry-run to see which copy was chosen).\n\n", len(list.resolved))
 	}
+	printAnomalies(list.anomalies)
+	printCollisions(list.collisions)
+	events.Emit(ui.Event{Type: ui.EventChaptersFetched, Series: cfg.DefaultURL, Count: len(list.all)})
+
+	return list, nil
+}
 
-	return allChapters, nil
+func printAnomalies(list []chapters.Anomaly) {
+	if len(list) == 0 {
+		return
+	}
+
+	ui.Println("Chapter list check:")
+	for _, a := range list {
+		ui.Printf("  %-8s %s\n", a.Severity.String()+":", a.Message)
+	}
+	ui.Println()
 }
 
-func selectChapters(all []chapters.Chapter, cfg *config.Config) ([]chapters.Chapter, error) {
+// selectChapters applies --chapter, `chapters` and the index flags. It
+// also reports whether the chapters were picked by their position in the
+// list rather than by their number.
+func selectChapters(all []chapters.Chapter, cfg *config.Config) ([]chapters.Chapter, bool, error) {
 	finalRange := firstNonEmpty(flagRange, cfg.DefaultRange)
 	finalExcludeRange := firstNonEmpty(flagExcludeRange, cfg.DefaultExcludeRange)
 	finalList := firstNonEmpty(flagList, cfg.DefaultList)
@@ -220,24 +544,104 @@ func selectChapters(all []chapters.Chapter, cfg *config.Config) ([]chapters.Chap
 	if flagChapter != "" {
 		direct := chapters.FilterChaptersByLabel(all, flagChapter)
 		if len(direct) > 0 {
-			return direct, nil
+			return direct, false, nil
 		}
 
 		var idx int
 		if _, err := fmt.Sscanf(flagChapter, "%d", &idx); err == nil && idx > 0 {
-			return chapters.Filter(all, strconv.Itoa(idx), finalRange, finalExcludeRange, finalList, finalExcludeList)
+			sel, err := chapters.Filter(all, strconv.Itoa(idx), finalRange, finalExcludeRange, finalList, finalExcludeList)
+			return sel, true, err
+		}
+
+		return nil, false, fmt.Errorf("chapter '%s' not found", flagChapter)
+	}
+
+	// index flags given on the command line win over an expression stored
+	// in the config
+	indexFlags := flagRange != "" || flagExcludeRange != "" || flagList != "" || flagExcludeList != ""
+	if cfg.Chapters != "" && (flagChapters != "" || !indexFlags) {
+		sel, err := chapters.ParseSelection(cfg.Chapters)
+		if err != nil {
+			return nil, false, err
+		}
+		return sel.Apply(all), false, nil
+	}
+
+	if !indexFlags && (cfg.DefaultRange != "" || cfg.DefaultList != "") {
+		ui.Println("Note: default_range/default_list select by position, which shifts when the site adds or removes chapters. Consider `chapters` (e.g. \"10-20,!13\") instead.")
+		ui.Println()
+	}
+
+	sel, err := chapters.Filter(all, "", finalRange, finalExcludeRange, finalList, finalExcludeList)
+	return sel, true, err
+}
+
+// recentFilter holds --since, --latest and --new-only.
+type recentFilter struct {
+	Since   string
+	Latest  int
+	NewOnly bool
+}
+
+func recentFlags() recentFilter {
+	return recentFilter{Since: flagSince, Latest: flagLatest, NewOnly: flagNewOnly}
+}
+
+// filterRecent applies --since, --latest and --new-only on top of the
+// chapter selection.
+func filterRecent(selected []chapters.Chapter, list chapterList, cfg *config.Config, f recentFilter) ([]chapters.Chapter, error) {
+	if (f.Since != "" || f.Latest > 0) && chapters.Dated(selected) == 0 {
+		if f.Since != "" {
+			return nil, fmt.Errorf("--since needs release dates, but none were found on the page")
+		}
+		ui.Println("Note: no release dates found on the page; --latest picks the highest chapter numbers instead.")
+	}
+
+	if f.Since != "" {
+		since, err := chapters.ParseSince(f.Since, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		if undated := len(selected) - chapters.Dated(selected); undated > 0 {
+			ui.Printf("Note: %d chapters without a release date are skipped by --since.\n", undated)
 		}
+		selected = chapters.Since(selected, since)
+	}
 
-		return nil, fmt.Errorf("chapter '%s' not found", flagChapter)
+	if f.Latest > 0 {
+		selected = chapters.Latest(selected, f.Latest)
 	}
 
-	return chapters.Filter(all, "", finalRange, finalExcludeRange, finalList, finalExcludeList)
+	if f.NewOnly {
+		selected = chapters.AfterNewest(selected, list.downloaded(cfg))
+		if len(selected) == 0 {
+			ui.Println("No chapters newer than the ones already in the output folder.")
+		}
+	}
+
+	return selected, nil
 }
 
-func doDryRun(ctx context.Context, scr *generic.Scraper, selected []chapters.Chapter) error {
+func doDryRun(ctx context.Context, scr *generic.Scraper, cfg *config.Config, selected []chapters.Chapter, list chapterList) error {
+	dupes := make(map[string]chapters.Resolution, len(list.resolved))
+	for _, r := range list.resolved {
+		dupes[r.Chosen.URL] = r
+	}
+
 	fmt.Printf("Dry-run: %d chapters selected.\n\n", len(selected))
 	for i, ch := range selected {
-		fmt.Printf("%3d) %s  [%s]\n    %s\n", i+1, ch.Title, ch.Label, ch.URL)
+		released := ""
+		if !ch.Date.IsZero() {
+			released = "  " + ch.Date.Format("2006-01-02")
+		}
+		fmt.Printf("%3d) %s  [%s]%s\n    %s\n    -> %s\n", i+1, ch.Title, ch.DisplayLabel(), released, ch.URL, list.cbzPath(cfg, ch))
+
+		if r, ok := dupes[ch.URL]; ok {
+			fmt.Printf("     chosen: %s (%s)\n", ch.Source(), r.Reason)
+			for _, d := range r.Dropped {
+				fmt.Printf("     skipped: %s  %s\n", d.Source(), d.URL)
+			}
+		}
 	}
 
 	if len(selected) == 1 {
@@ -264,13 +668,48 @@ func doDryRun(ctx context.Context, scr *generic.Scraper, selected []chapters.Cha
 	return nil
 }
 
-func performDownloads(ctx context.Context, scr *generic.Scraper, client *http.Client, cfg *config.Config, logSvc *ui.Logger, selected []chapters.Chapter) error {
+func performDownloads(ctx context.Context, scr *generic.Scraper, client *http.Client, cfg *config.Config, logSvc *ui.Logger, list chapterList, selected []chapters.Chapter) error {
 	pm := ui.NewProgressManager(cfg.ChapterWorkers)
 	defer pm.Close()
 
-	stats := &ui.Stats{}
-	dl := downloader.New(client, cfg.Debug, cfg.Output, cfg.SkipBroken)
 	start := time.Now()
+	stats, failures := downloadChapters(ctx, scr, client, cfg, logSvc, pm, list.names, "", selected)
+	pm.Close()
+
+	fmt.Println()
+	fmt.Println("Download Summary:")
+	fmt.Printf("Chapters: %d\n", stats.TotalChapters.Load())
+	fmt.Printf("Images:   %d\n", stats.TotalImages.Load())
+	fmt.Printf("Data:     %s\n", util.Human(stats.TotalBytes.Load()))
+	fmt.Printf("Time:     %s\n", time.Since(start).Round(time.Second))
+	if len(failures) > 0 {
+		report.Print(os.Stdout, failures)
+	}
+	events.Summary(stats, time.Since(start))
+	writeLibraryFiles(ctx, client, cfg, list, logSvc)
+
+	if err := report.Update(cfg.Output, cfg.DefaultURL, failures); err != nil {
+		logSvc.Warn("Cannot write failures file", "path", report.Path(cfg.Output), "err", err)
+	}
+	if len(failures) > 0 {
+		fmt.Printf("\nFailures written to %s; re-run them with --retry-failed.\n", report.Path(cfg.Output))
+		return failureExit(int(stats.Failed.Load()), len(failures), len(selected))
+	}
+
+	fmt.Println("\nAll done.")
+	return nil
+}
+
+// downloadChapters downloads the selected chapters into cfg.Output, named by
+// names, with cfg.ChapterWorkers in parallel. Bars are labeled with prefix
+// followed by the chapter label.
+func downloadChapters(ctx context.Context, scr *generic.Scraper, client *http.Client, cfg *config.Config, logSvc *ui.Logger, pm *ui.MPBProgressManager, names *chapters.Namer, prefix string, selected []chapters.Chapter) (*ui.Stats, []report.Failure) {
+	stats := &ui.Stats{}
+	dl := downloader.New(client, cfg.Debug, cfg.Output, cfg.SkipBroken, imgfmt.NewSet(cfg.AllowExt))
+	tc, _ := transcodeOptions(cfg) // checked by setupEnvironment
+
+	var mu sync.Mutex
+	var failures []report.Failure
 
 	sem := make(chan struct{}, max(1, cfg.ChapterWorkers))
 	var wg sync.WaitGroup
@@ -283,29 +722,74 @@ func performDownloads(ctx context.Context, scr *generic.Scraper, client *http.Cl
 			defer wg.Done()
 			defer func() { <-sem }()
 
+			ev := ui.Event{Series: cfg.DefaultURL, Chapter: ch.Label, URL: ch.URL}
+			failure := report.Failure{Series: cfg.DefaultURL, Chapter: ch.Label, Title: ch.Title, URL: ch.URL}
+			failed := func(f report.Failure) {
+				mu.Lock()
+				failures = append(failures, f)
+				mu.Unlock()
+
+				e := ev
+				e.Type, e.Reason = ui.EventChapterFailed, string(f.Kind)+": "+f.Reason
+				events.Emit(e)
+			}
+
+			clog := logSvc.With("chapter", ch.Label, "url", ch.URL)
+
 			images, err := scr.GetImages(ctx, ch.URL)
 			if err != nil || len(images) == 0 {
-				logSvc.Errorf("No images for %s (%s): %v", ch.Title, ch.Label, err)
+				clog.Error("No images found", "stage", "images", "err", err)
+				stats.Failed.Add(1)
+				failed(failure.Classify(err, report.KindNoImages))
 				return
 			}
 
-			handle := pm.Register("Ch." + ch.Label)
+			started := ev
+			started.Type, started.Total = ui.EventChapterStarted, len(images)
+			events.Emit(started)
+
+			handle := pm.Register(prefix+ch.DisplayLabel()).WithEvents(events, ev)
 			handle.SetTotal(len(images))
 
-			tmpFolder := filepath.Join(cfg.Output, ch.FolderName()+"_tmp")
-			cbzOut := filepath.Join(cfg.Output, ch.OutputCBZ())
+			cbzOut := filepath.Join(cfg.Output, names.Path(ch))
+			tmpFolder := strings.TrimSuffix(cbzOut, filepath.Ext(cbzOut)) + "_tmp"
+			defer util.TrackTempFolder(tmpFolder)()
 
 			files, bytes, err := dl.DownloadImagesConcurrently(ctx, images, tmpFolder, ch.URL, max(1, cfg.ImageWorkers), handle)
-			if err != nil {
-				logSvc.Errorf("Chapter %s failed: %v", ch.Label, err)
+			var partial *downloader.PartialError
+			if errors.As(err, &partial) && partial.Skipped {
+				// the chapter is still written, but recorded for --retry-failed
+				clog.Warn("Pages skipped", "stage", "download", "pages", len(partial.Pages), "err", partial.Err)
+				failed(failure.Classify(err, report.KindOther))
+			} else if err != nil {
+				clog.Error("Chapter failed", "stage", "download", "err", err)
 				_ = os.RemoveAll(tmpFolder)
+				stats.Failed.Add(1)
+				failed(failure.Classify(err, report.KindOther))
 
 				return
 			}
 
-			if err := util.CreateCBZ(files, cbzOut); err != nil {
-				logSvc.Errorf("CBZ for %s failed: %v", ch.Label, err)
+			if tc.Enabled() {
+				if files, err = transcode.Pages(files, tc, cfg.KeepFolders); err != nil {
+					clog.Warn("Some pages were archived unconverted", "stage", "transcode", "err", err)
+				}
+			}
+
+			var missing []int
+			if partial != nil {
+				missing = partial.Pages
+			}
+			info, err := ch.ComicInfo(names.Series(), len(files), missing)
+			if err != nil {
+				clog.Warn("Cannot write ComicInfo.xml", "err", err)
+			}
+
+			if err := util.CreateCBZ(files, info, cbzOut); err != nil {
+				clog.Error("CBZ failed", "stage", "cbz", "path", cbzOut, "err", err)
 				_ = os.RemoveAll(tmpFolder)
+				stats.Failed.Add(1)
+				failed(failure.Classify(err, report.KindCBZ))
 
 				return
 			}
@@ -314,6 +798,13 @@ func performDownloads(ctx context.Context, scr *generic.Scraper, client *http.Cl
 				util.CleanupFolder(tmpFolder)
 			}
 
+			written := ev
+			written.Type, written.Path, written.Total = ui.EventCBZWritten, cbzOut, len(files)
+			if fi, err := os.Stat(cbzOut); err == nil {
+				written.Bytes = fi.Size()
+			}
+			events.Emit(written)
+
 			handle.MarkDone()
 			stats.TotalChapters.Add(1)
 			stats.TotalImages.Add(int64(len(files)))
@@ -321,17 +812,8 @@ func performDownloads(ctx context.Context, scr *generic.Scraper, client *http.Cl
 		}(ch)
 	}
 	wg.Wait()
-	pm.Close()
 
-	fmt.Println()
-	fmt.Println("Download Summary:")
-	fmt.Printf("Chapters: %d\n", stats.TotalChapters.Load())
-	fmt.Printf("Images:   %d\n", stats.TotalImages.Load())
-	fmt.Printf("Data:     %s\n", util.Human(stats.TotalBytes.Load()))
-	fmt.Printf("Time:     %s\n", time.Since(start).Round(time.Second))
-	fmt.Println("\nAll done.")
-
-	return nil
+	return stats, failures
 }
 
 func firstNonEmpty(a, b string) string {
@@ -342,6 +824,18 @@ func firstNonEmpty(a, b string) string {
 	return b
 }
 
+// splitList splits a "|" or "," separated list, keeping case and inner spaces.
+func splitList(s string) []string {
+	out := []string{}
+	for f := range strings.SplitSeq(strings.ReplaceAll(s, "|", ","), ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			out = append(out, f)
+		}
+	}
+
+	return out
+}
+
 func splitExt(s string) []string {
 	fields := strings.FieldsFunc(s, func(r rune) bool {
 		return r == '|' || r == ',' || r == ' '
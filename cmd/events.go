@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/brogergvhs/mangad/internal/ui"
+)
+
+// events receives the --events stream; nil when it is disabled.
+var events *ui.EventSink
+
+// setupEvents opens the --events sink. When events go to stdout, all human
+// output (messages and progress bars) is moved to stderr so stdout stays
+// valid NDJSON.
+func setupEvents() (func(), error) {
+	if flagEvents == "" {
+		return func() {}, nil
+	}
+	if flagEvents != "json" {
+		return nil, fmt.Errorf("unknown --events format %q (only json is supported)", flagEvents)
+	}
+
+	out := os.Stdout
+	sink, err := ui.NewEventSink(flagEventsFile, stdout)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open events file: %w", err)
+	}
+	if flagEventsFile == "" || flagEventsFile == "-" {
+		os.Stdout = os.Stderr
+	}
+
+	events = sink
+	return func() {
+		_ = sink.Close()
+		os.Stdout = out
+	}, nil
+}
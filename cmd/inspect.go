@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/brogergvhs/mangad/internal/config"
+	"github.com/brogergvhs/mangad/internal/providers/generic"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	flagInspectURL  string
+	flagInspectJSON bool
+)
+
+func init() {
+	inspectCmd := &cobra.Command{
+		Use:   "inspect",
+		Short: "Explain which chapter links and images are found on a page and why",
+		RunE:  runInspect,
+	}
+
+	inspectCmd.Flags().StringVar(&flagInspectURL, "url", "", "series or chapter page URL")
+	inspectCmd.Flags().BoolVar(&flagInspectJSON, "json", false, "print the report as JSON")
+
+	rootCmd.AddCommand(inspectCmd)
+}
+
+func runInspect(_ *cobra.Command, _ []string) error {
+	cfg, _, err := config.LoadMerged(config.Options{
+		IgnoreConfig: flagIgnoreConfig,
+		Debug:        flagDebug,
+		DefaultURL:   flagInspectURL,
+	})
+	if err != nil {
+		return err
+	}
+	if cfg.DefaultURL == "" {
+		return fmt.Errorf("missing --url and no default_url in config")
+	}
+
+	logSvc, err := newLogger(cfg)
+	if err != nil {
+		return err
+	}
+
+	_, scr, ctx, err := setupEnvironment(cfg, logSvc)
+	if err != nil {
+		return err
+	}
+
+	rep, err := scr.Inspect(ctx, cfg.DefaultURL)
+	if err != nil {
+		return err
+	}
+
+	if flagInspectJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(rep)
+	}
+
+	printInspectReport(rep)
+	return nil
+}
+
+func printInspectReport(rep *generic.Report) {
+	fmt.Printf("Page: %s\n\n", rep.URL)
+
+	accepted := 0
+	for _, l := range rep.Links {
+		if l.Accepted {
+			accepted++
+		}
+	}
+	fmt.Printf("Chapter links: %d accepted, %d rejected, %d chapters\n", accepted, len(rep.Links)-accepted, len(rep.Chapters))
+	if rep.Container != "" {
+		fmt.Printf("Chapter list container: %s\n", rep.Container)
+	}
+	fmt.Println()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "STATUS\tLABEL\tTEXT\tHREF\tREASON")
+	for _, l := range rep.Links {
+		status := "rejected"
+		if l.Accepted {
+			status = "accepted"
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", status, l.Label, truncate(l.Text, 40), l.Href, l.Reason)
+	}
+	if err := w.Flush(); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: failed to flush table output: %v\n", err)
+	}
+
+	fmt.Printf("\nImage candidates: %d, final pages: %d\n", len(rep.Candidates), len(rep.Images))
+	if rep.ImageError != "" {
+		fmt.Printf("Image extraction: %s\n", rep.ImageError)
+	}
+	fmt.Println()
+
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "PAGE\tSTAGE\tINDEX\tSTATUS\tGROUP\tURL\tREASON")
+	for _, c := range rep.Candidates {
+		page, status, idx := "-", "dropped", "-"
+		if c.Kept {
+			page, status = strconv.Itoa(c.Page), "kept"
+		}
+		if c.Index >= 0 {
+			idx = strconv.Itoa(c.Index)
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", page, c.Stage, idx, status, c.Group, c.URL, c.Reason)
+	}
+	if err := w.Flush(); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: failed to flush table output: %v\n", err)
+	}
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+
+	return string(r[:n-1]) + "…"
+}
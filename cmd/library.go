@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/brogergvhs/mangad/internal/config"
+	"github.com/brogergvhs/mangad/internal/library"
+	"github.com/brogergvhs/mangad/internal/providers"
+	"github.com/brogergvhs/mangad/internal/providers/generic"
+	"github.com/brogergvhs/mangad/internal/ui"
+)
+
+// Output layouts.
+const (
+	layoutFlat    = "flat"    // CBZs straight in the output folder
+	layoutLibrary = "library" // <output>/<Series>/ with series.json and cover.jpg
+)
+
+// loadSeries reads the series page metadata when something uses it: the
+// library layout or a name template. Failures only cost the metadata.
+func loadSeries(ctx context.Context, scr *generic.Scraper, cfg *config.Config) providers.Series {
+	info := providers.Series{URL: cfg.DefaultURL}
+	if cfg.Layout != layoutLibrary && cfg.NameTemplate == "" {
+		return info
+	}
+
+	got, err := scr.GetSeries(ctx, cfg.DefaultURL)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Note: cannot read the series details (%v); naming it after the URL.\n", err)
+		return info
+	}
+
+	return got
+}
+
+// seriesNameFor picks the series name: series_name from the config, the
+// title found on the page, or the last part of the URL.
+func seriesNameFor(cfg *config.Config, info providers.Series) string {
+	switch {
+	case cfg.SeriesName != "":
+		return cfg.SeriesName
+	case info.Title != "":
+		return info.Title
+	default:
+		return seriesTitle(cfg.DefaultURL)
+	}
+}
+
+// writeLibraryFiles writes series.json and cover.jpg into the series folder
+// in the library layout. Problems are logged; the CBZs are what matters.
+func writeLibraryFiles(ctx context.Context, client *http.Client, cfg *config.Config, list chapterList, logSvc *ui.Logger) {
+	if cfg.Layout != layoutLibrary {
+		return
+	}
+
+	dir := filepath.Join(cfg.Output, list.names.SeriesFolder())
+	log := logSvc.With("series", list.names.SeriesFolder(), "stage", "library")
+
+	raw := make([]providers.Chapter, len(list.all))
+	for i, ch := range list.all {
+		raw[i] = ch.Chapter
+	}
+	if err := library.WriteSeries(dir, seriesNameFor(cfg, list.series), list.series, raw); err != nil {
+		log.Warn("Cannot write "+library.SeriesFile, "err", err)
+	}
+
+	if list.series.Cover == "" {
+		log.Debug("No cover image found on the series page")
+		return
+	}
+	if err := library.SaveCover(ctx, client, list.series.Cover, cfg.DefaultURL, dir); err != nil {
+		log.Warn("Cannot save "+library.CoverFile, "url", list.series.Cover, "err", err)
+	}
+}
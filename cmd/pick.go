@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/brogergvhs/mangad/internal/chapters"
+	"github.com/brogergvhs/mangad/internal/config"
+
+	"github.com/manifoldco/promptui"
+)
+
+// pickActions are the rows above the chapter list. promptui has no
+// multi-select or custom keys, so each action is a row of its own.
+var pickActions = []string{
+	"» Done",
+	"» Select all",
+	"» Invert selection",
+	"» Select by range or expression…",
+	"» Clear selection",
+}
+
+const (
+	actDone = iota
+	actAll
+	actInvert
+	actExpr
+	actClear
+)
+
+// pickChapters shows a searchable list of all chapters where Enter toggles
+// a chapter. It returns the chosen chapters in list order.
+func pickChapters(all []chapters.Chapter, have func(chapters.Chapter) bool) ([]chapters.Chapter, error) {
+	keep := make([]bool, len(all))
+	downloaded := make([]bool, len(all))
+	for i, ch := range all {
+		downloaded[i] = have(ch)
+	}
+
+	cursor, scroll := len(pickActions), 0
+	for {
+		n := 0
+		items := append([]string{}, pickActions...)
+		for i, ch := range all {
+			if keep[i] {
+				n++
+			}
+			items = append(items, pickRow(i, ch, keep[i], downloaded[i]))
+		}
+
+		sel := promptui.Select{
+			Label:        fmt.Sprintf("Chapters: %d selected (Enter toggles, / searches)", n),
+			Items:        items,
+			Size:         20,
+			HideSelected: true,
+			Searcher: func(input string, index int) bool {
+				return strings.Contains(strings.ToLower(items[index]), strings.ToLower(input))
+			},
+		}
+
+		idx, _, err := sel.RunCursorAt(cursor, scroll)
+		if err != nil {
+			return nil, fmt.Errorf("selection cancelled")
+		}
+		cursor, scroll = idx, sel.ScrollPosition()
+
+		switch idx {
+		case actDone:
+			var out []chapters.Chapter
+			for i, ok := range keep {
+				if ok {
+					out = append(out, all[i])
+				}
+			}
+			return out, nil
+		case actAll:
+			for i := range keep {
+				keep[i] = true
+			}
+		case actInvert:
+			for i := range keep {
+				keep[i] = !keep[i]
+			}
+		case actExpr:
+			if err := selectByExpression(all, keep); err != nil {
+				fmt.Println(err)
+			}
+		case actClear:
+			clear(keep)
+		default:
+			keep[idx-len(pickActions)] = !keep[idx-len(pickActions)]
+		}
+	}
+}
+
+func pickRow(i int, ch chapters.Chapter, selected, downloaded bool) string {
+	mark := "[ ]"
+	if selected {
+		mark = "[x]"
+	}
+
+	row := fmt.Sprintf("%s %4d  %-14s %s", mark, i+1, ch.DisplayLabel(), truncate(ch.Title, 50))
+	if !ch.Date.IsZero() {
+		row += "  " + ch.Date.Format("2006-01-02")
+	}
+	if downloaded {
+		row += "  ✓ downloaded"
+	}
+
+	return row
+}
+
+// selectByExpression adds the chapters matching a --chapters style
+// expression (e.g. "10-20,!13,extras") to the selection.
+func selectByExpression(all []chapters.Chapter, keep []bool) error {
+	prompt := promptui.Prompt{Label: "Chapters (e.g. 10-20,!13,vol:3,extras)"}
+	expr, err := prompt.Run()
+	if err != nil {
+		return nil
+	}
+
+	sel, err := chapters.ParseSelection(expr)
+	if err != nil {
+		return err
+	}
+
+	urls := map[string]bool{}
+	for _, ch := range sel.Apply(all) {
+		urls[ch.URL] = true
+	}
+	for i, ch := range all {
+		if urls[ch.URL] {
+			keep[i] = true
+		}
+	}
+
+	return nil
+}
+
+// offerSaveSelection stores the picked chapters as the `chapters`
+// expression of the active config when the user agrees. It is only offered
+// when the series URL came from that config.
+func offerSaveSelection(all, picked []chapters.Chapter) error {
+	if flagIgnoreConfig || flagURL != "" || len(picked) == 0 {
+		return nil
+	}
+	if _, err := config.ActiveConfigPath(); err != nil {
+		return nil
+	}
+
+	expr, err := chapters.Expression(all, picked)
+	if err != nil {
+		fmt.Printf("Note: this selection can't be saved as a `chapters` expression (%v).\n\n", err)
+		return nil
+	}
+
+	confirm := promptui.Prompt{Label: "Save this selection as the default in the active config", IsConfirm: true}
+	if _, err := confirm.Run(); err != nil {
+		if errors.Is(err, promptui.ErrInterrupt) {
+			return fmt.Errorf("selection cancelled")
+		}
+		return nil
+	}
+
+	path, err := config.UpdateActive(func(c *config.Config) { c.Chapters = expr })
+	if err != nil {
+		return fmt.Errorf("failed to save selection: %w", err)
+	}
+
+	fmt.Printf("Saved chapters: %s to %s\n\n", expr, path)
+	return nil
+}
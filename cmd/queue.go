@@ -0,0 +1,345 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+
+	"github.com/brogergvhs/mangad/internal/chapters"
+	"github.com/brogergvhs/mangad/internal/config"
+	"github.com/brogergvhs/mangad/internal/report"
+	"github.com/brogergvhs/mangad/internal/ui"
+	"github.com/brogergvhs/mangad/internal/util"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// queueFile is the --queue file. Every series entry may set any config key
+// (output, chapters, image_workers, cookie, allow_ext, ...) on top of the
+// active config, plus the queue keys in queueEntry.
+type queueFile struct {
+	Concurrency int         `yaml:"concurrency"` // series downloaded at the same time, default 1
+	OnError     string      `yaml:"on_error"`    // continue (default) or stop
+	Series      []yaml.Node `yaml:"series"`
+}
+
+type queueEntry struct {
+	Name  string `yaml:"name"`
+	URL   string `yaml:"url"`
+	Skip  bool   `yaml:"skip"`
+	Force bool   `yaml:"force"`
+
+	Since   string `yaml:"since"`
+	Latest  int    `yaml:"latest"`
+	NewOnly bool   `yaml:"new_only"`
+}
+
+func (e queueEntry) recent() recentFilter {
+	return recentFilter{Since: e.Since, Latest: e.Latest, NewOnly: e.NewOnly}
+}
+
+type queueJob struct {
+	entry queueEntry
+	cfg   *config.Config
+}
+
+type queueResult struct {
+	Name     string
+	Output   string
+	Status   string // done, failed, skipped, not started, up to date
+	Selected int
+	Stats    *ui.Stats
+	Failures []report.Failure
+	Err      error
+}
+
+func runQueue(cmd *cobra.Command) error {
+	if flagURL != "" || flagChapter != "" || flagChapters != "" || flagRange != "" || flagList != "" ||
+		flagExcludeRange != "" || flagExcludeList != "" || flagInteractive {
+		return fmt.Errorf("--queue can't be combined with --url, chapter selection or --interactive; set them per entry in the queue file")
+	}
+
+	base, usedPath, err := loadDownloadConfig(cmd)
+	if err != nil {
+		return err
+	}
+	if usedPath != "" {
+		fmt.Printf("Config file: %s\n", usedPath)
+	}
+
+	q, jobs, err := loadQueue(flagQueue, base)
+	if err != nil {
+		return err
+	}
+
+	logSvc, err := newLogger(base)
+	if err != nil {
+		return err
+	}
+	cmd.SilenceUsage = true
+	fmt.Printf("Queue: %d series, %d at a time\n\n", len(jobs), q.Concurrency)
+
+	var pm *ui.MPBProgressManager
+	if !flagDryRun {
+		pm = ui.NewProgressManager(base.ChapterWorkers)
+	}
+
+	start := time.Now()
+	results := make([]queueResult, len(jobs))
+	var stop atomic.Bool
+
+	sem := make(chan struct{}, q.Concurrency)
+	var wg sync.WaitGroup
+
+	for i, job := range jobs {
+		results[i] = queueResult{Name: job.entry.Name, Output: job.cfg.Output, Status: "not started"}
+		if job.entry.Skip {
+			results[i].Status = "skipped"
+			continue
+		}
+
+		sem <- struct{}{}
+		if stop.Load() {
+			<-sem
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			results[i] = runQueueJob(job, logSvc, pm)
+			if results[i].Err != nil && q.OnError == "stop" {
+				stop.Store(true)
+			}
+		}()
+	}
+	wg.Wait()
+	if pm != nil {
+		pm.Close()
+	}
+
+	return printQueueSummary(results, time.Since(start))
+}
+
+// loadQueue reads the queue file and builds one config per entry: the base
+// config without its series-specific keys, overlaid with the entry.
+func loadQueue(file string, base *config.Config) (*queueFile, []queueJob, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var q queueFile
+	if err := yaml.Unmarshal(b, &q); err != nil {
+		return nil, nil, fmt.Errorf("failed to load queue %s: %w", file, err)
+	}
+	if q.Concurrency <= 0 {
+		q.Concurrency = 1
+	}
+	switch q.OnError {
+	case "":
+		q.OnError = "continue"
+	case "continue", "stop":
+	default:
+		return nil, nil, fmt.Errorf("queue on_error must be continue or stop, not %q", q.OnError)
+	}
+	if len(q.Series) == 0 {
+		return nil, nil, fmt.Errorf("queue %s has no series", file)
+	}
+
+	jobs := make([]queueJob, 0, len(q.Series))
+	for i := range q.Series {
+		node := &q.Series[i]
+
+		var entry queueEntry
+		if err := node.Decode(&entry); err != nil {
+			return nil, nil, fmt.Errorf("queue entry %d: %w", i+1, err)
+		}
+
+		cfg := *base
+		cfg.DefaultURL = ""
+		cfg.SeriesName = ""
+		cfg.ClearSelection()
+		if err := node.Decode(&cfg); err != nil {
+			return nil, nil, fmt.Errorf("queue entry %d: %w", i+1, err)
+		}
+
+		if entry.URL != "" {
+			cfg.DefaultURL = entry.URL
+		}
+		if cfg.DefaultURL == "" {
+			return nil, nil, fmt.Errorf("queue entry %d has no url", i+1)
+		}
+		if entry.Name == "" {
+			entry.Name = seriesName(cfg.DefaultURL)
+		}
+		if entry.recent() == (recentFilter{}) {
+			f := recentFlags()
+			entry.Since, entry.Latest, entry.NewOnly = f.Since, f.Latest, f.NewOnly
+		}
+		entry.Force = entry.Force || flagForce
+
+		jobs = append(jobs, queueJob{entry: entry, cfg: &cfg})
+	}
+
+	return &q, jobs, nil
+}
+
+func runQueueJob(job queueJob, logSvc *ui.Logger, pm *ui.MPBProgressManager) queueResult {
+	cfg := job.cfg
+	res := queueResult{Name: job.entry.Name, Output: cfg.Output, Status: "failed"}
+
+	fail := func(err error) queueResult {
+		res.Err = err
+		logSvc.Error("Queue entry failed", "series", job.entry.Name, "err", err)
+		return res
+	}
+
+	if err := os.MkdirAll(cfg.Output, 0755); err != nil {
+		return fail(fmt.Errorf("cannot create output folder: %w", err))
+	}
+
+	client, scr, ctx, err := setupEnvironment(cfg, logSvc)
+	if err != nil {
+		return fail(err)
+	}
+
+	ui.Printf("== %s (%s)\n", job.entry.Name, cfg.DefaultURL)
+	list, err := fetchAllChapters(ctx, scr, cfg)
+	if err != nil {
+		return fail(err)
+	}
+	if len(list.all) == 0 {
+		return fail(errors.New("no chapters found"))
+	}
+	var selected []chapters.Chapter
+	var byIndex bool
+	if flagRetryFailed {
+		selected, err = retrySelection(list.all, cfg)
+	} else if selected, byIndex, err = selectChapters(list.all, cfg); err == nil {
+		selected, err = filterRecent(selected, list, cfg, job.entry.recent())
+	}
+	if err != nil {
+		return fail(err)
+	}
+	if byIndex && !flagDryRun && !job.entry.Force && chapters.HasSevere(list.anomalies) {
+		return fail(errors.New("chapter list check found severe anomalies; select chapters with `chapters` or set force: true to download anyway"))
+	}
+
+	res.Selected = len(selected)
+	if len(selected) == 0 {
+		res.Status = "up to date"
+		return res
+	}
+
+	if flagDryRun {
+		if err := doDryRun(ctx, scr, cfg, selected, list); err != nil {
+			return fail(err)
+		}
+		res.Status = "dry run"
+		return res
+	}
+
+	res.Stats, res.Failures = downloadChapters(ctx, scr, client, cfg, logSvc, pm, list.names, job.entry.Name+" ", selected)
+	writeLibraryFiles(ctx, client, cfg, list, logSvc)
+	if err := report.Update(cfg.Output, cfg.DefaultURL, res.Failures); err != nil {
+		logSvc.Warn("Cannot write failures file", "series", job.entry.Name, "path", report.Path(cfg.Output), "err", err)
+	}
+	if n := len(res.Failures); n > 0 {
+		return fail(fmt.Errorf("%d of %d chapters failed", n, len(selected)))
+	}
+	res.Status = "done"
+
+	return res
+}
+
+func printQueueSummary(results []queueResult, elapsed time.Duration) error {
+	fmt.Println()
+	fmt.Println("Queue Summary:")
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "SERIES\tSTATUS\tSELECTED\tCHAPTERS\tFAILED\tIMAGES\tDATA\tOUTPUT")
+
+	var chaptersTotal, failedTotal, imagesTotal, bytesTotal int64
+	failedEntries, startedEntries := 0, 0
+	for _, r := range results {
+		var ch, failed, images, bytes int64
+		if r.Stats != nil {
+			ch, failed = r.Stats.TotalChapters.Load(), r.Stats.Failed.Load()
+			images, bytes = r.Stats.TotalImages.Load(), r.Stats.TotalBytes.Load()
+		}
+		chaptersTotal += ch
+		failedTotal += failed
+		imagesTotal += images
+		bytesTotal += bytes
+		if r.Err != nil {
+			failedEntries++
+		}
+		if r.Status != "skipped" && r.Status != "not started" {
+			startedEntries++
+		}
+
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\t%d\t%s\t%s\n",
+			r.Name, r.Status, r.Selected, ch, failed, images, util.Human(bytes), r.Output)
+	}
+	if err := w.Flush(); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: failed to flush table output: %v\n", err)
+	}
+
+	fmt.Println()
+	fmt.Printf("Series:   %d\n", len(results))
+	fmt.Printf("Chapters: %d (%d failed)\n", chaptersTotal, failedTotal)
+	fmt.Printf("Images:   %d\n", imagesTotal)
+	fmt.Printf("Data:     %s\n", util.Human(bytesTotal))
+	fmt.Printf("Time:     %s\n", elapsed.Round(time.Second))
+	for _, r := range results {
+		if len(r.Failures) > 0 {
+			fmt.Printf("\n%s (%s):\n", r.Name, report.Path(r.Output))
+			report.Print(os.Stdout, r.Failures)
+		}
+	}
+
+	total := &ui.Stats{}
+	total.TotalChapters.Store(chaptersTotal)
+	total.TotalImages.Store(imagesTotal)
+	total.TotalBytes.Store(bytesTotal)
+	total.Failed.Store(failedTotal)
+	events.Summary(total, elapsed)
+
+	if failedEntries > 0 {
+		code := exitPartial
+		if failedEntries == startedEntries && chaptersTotal == 0 {
+			code = exitFailed
+		}
+		return &exitCodeError{code: code, err: fmt.Errorf("%d of %d queue entries failed", failedEntries, len(results))}
+	}
+
+	fmt.Println("\nAll done.")
+	return nil
+}
+
+// seriesName derives a short name from a series URL ("/manga/one-piece/"
+// gives "one-piece").
+func seriesName(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+
+	name := path.Base(strings.TrimRight(u.Path, "/"))
+	if name == "." || name == "/" || name == "" {
+		return u.Host
+	}
+
+	return name
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"github.com/brogergvhs/mangad/internal/chapters"
+	"github.com/brogergvhs/mangad/internal/config"
+	"github.com/brogergvhs/mangad/internal/report"
+	"github.com/brogergvhs/mangad/internal/ui"
+)
+
+// retrySelection returns the chapters of cfg.DefaultURL listed in the
+// failures file of cfg.Output, matched by URL. A failure whose URL is gone
+// (the site moved its chapters) falls back to its label, but only when
+// exactly one chapter carries it.
+func retrySelection(all []chapters.Chapter, cfg *config.Config) ([]chapters.Chapter, error) {
+	f, err := report.Load(cfg.Output)
+	if err != nil {
+		return nil, err
+	}
+
+	byURL := make(map[string]int, len(all))
+	byLabel := make(map[string][]int, len(all))
+	for i, ch := range all {
+		byURL[ch.URL] = i
+		byLabel[ch.Label] = append(byLabel[ch.Label], i)
+	}
+
+	failed := f.Series(cfg.DefaultURL)
+	keep := make([]bool, len(all))
+	missing := 0
+	for _, fl := range failed {
+		if i, ok := byURL[fl.URL]; ok {
+			keep[i] = true
+		} else if idx := byLabel[fl.Chapter]; len(idx) == 1 {
+			keep[idx[0]] = true
+		} else {
+			missing++
+		}
+	}
+
+	var out []chapters.Chapter
+	for i, ch := range all {
+		if keep[i] {
+			out = append(out, ch)
+		}
+	}
+
+	if missing > 0 {
+		ui.Printf("Note: %d failed chapters are no longer listed on the series page\n", missing)
+	}
+	if len(failed) > 0 {
+		ui.Printf("Retrying %d failed chapters from %s\n\n", len(out), report.Path(cfg.Output))
+	}
+
+	return out, nil
+}
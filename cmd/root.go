@@ -1,30 +1,125 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
+	"github.com/brogergvhs/mangad/internal/config"
+	"github.com/brogergvhs/mangad/internal/ui"
+
 	"github.com/spf13/cobra"
 )
 
 var (
 	flagIgnoreConfig bool
 	flagDebug        bool
+	flagLogLevel     string
+	flagLogFile      string
+	flagQuiet        bool
+	flagPlain        bool
 )
 
+// stdout is the real standard output. Requested data (JSON, CSV, tables,
+// events) goes here even when --quiet or --events moves human output away.
+var stdout = os.Stdout
+
 var rootCmd = &cobra.Command{
-	Use:   "mangad",
-	Short: "Manga downloader with CBZ output",
+	Use:               "mangad",
+	Short:             "Manga downloader with CBZ output",
+	PersistentPreRunE: setupConsole,
 }
 
 func init() {
 	rootCmd.PersistentFlags().BoolVar(&flagDebug, "debug", false, "enable debug logging")
 	rootCmd.PersistentFlags().BoolVar(&flagIgnoreConfig, "ignore-config", false, "ignore config and use only CLI flags")
+	rootCmd.PersistentFlags().StringVar(&flagLogLevel, "log-level", "", "console log level: trace, debug, info, warn or error (default info, debug with --debug)")
+	rootCmd.PersistentFlags().StringVar(&flagLogFile, "log-file", "", "also write debug logs to this file (rotated at 10 MB, 3 old files kept)")
+	rootCmd.PersistentFlags().BoolVarP(&flagQuiet, "quiet", "q", false, "only print warnings, errors and requested output")
+	rootCmd.PersistentFlags().BoolVar(&flagPlain, "plain", false, "no progress bars; one line per finished chapter and timestamped logs (default when stdout is not a terminal)")
+}
+
+func setupConsole(_ *cobra.Command, _ []string) error {
+	if flagLogLevel != "" {
+		if _, err := ui.ParseLevel(flagLogLevel); err != nil {
+			return err
+		}
+	}
+
+	// decided before --quiet swaps stdout: /dev/null looks like a terminal.
+	// Quiet runs show no bars, so warnings go straight to stderr.
+	ui.SetPlain(flagPlain || flagQuiet)
+	if flagQuiet {
+		devnull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+		if err != nil {
+			return err
+		}
+		os.Stdout = devnull
+	}
+
+	return nil
+}
+
+// newLogger builds the logger for cfg from the --log-level, --log-file,
+// --quiet and --plain flags. --log-level debug or trace also turns on
+// cfg.Debug.
+func newLogger(cfg *config.Config) (*ui.Logger, error) {
+	level := ui.LevelInfo
+	if cfg.Debug {
+		level = ui.LevelDebug
+	}
+	if flagLogLevel != "" {
+		var err error
+		if level, err = ui.ParseLevel(flagLogLevel); err != nil {
+			return nil, err
+		}
+		cfg.Debug = cfg.Debug || level <= ui.LevelDebug
+	}
+
+	return ui.NewLoggerWith(ui.LoggerOptions{
+		Level: level,
+		Quiet: flagQuiet,
+		Plain: flagPlain || !ui.IsTerminal(stdout),
+		File:  flagLogFile,
+	})
+}
+
+// Exit codes.
+const (
+	exitError   = 1 // bad flags or config, or a run that could not start
+	exitPartial = 2 // some chapters failed
+	exitFailed  = 3 // every chapter failed
+)
+
+// exitCodeError makes Execute exit with code instead of exitError.
+type exitCodeError struct {
+	code int
+	err  error
+}
+
+func (e *exitCodeError) Error() string { return e.err.Error() }
+func (e *exitCodeError) Unwrap() error { return e.err }
+
+// failureExit is the error for a run where failed of total chapters were
+// not written and reported chapters (failed ones plus those written with
+// skipped pages) ended up in the failures file.
+func failureExit(failed, reported, total int) error {
+	if failed >= total {
+		return &exitCodeError{code: exitFailed, err: fmt.Errorf("all %d chapters failed", total)}
+	}
+
+	return &exitCodeError{code: exitPartial, err: fmt.Errorf("%d of %d chapters failed", reported, total)}
 }
 
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+		fmt.Fprintln(os.Stderr, err)
+
+		code := exitError
+		var ce *exitCodeError
+		if errors.As(err, &ce) {
+			code = ce.code
+		}
+		os.Exit(code)
 	}
 }
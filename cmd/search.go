@@ -0,0 +1,225 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/brogergvhs/mangad/internal/config"
+	"github.com/brogergvhs/mangad/internal/search"
+	"github.com/brogergvhs/mangad/internal/ui"
+
+	"github.com/manifoldco/promptui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	flagSearchSite     string
+	flagSearchLimit    int
+	flagSearchNoPrompt bool
+	flagSearchJSON     bool
+)
+
+func init() {
+	searchCmd := &cobra.Command{
+		Use:   "search <query>",
+		Short: "Search the configured sites for a series",
+		Args:  cobra.MinimumNArgs(1),
+		RunE:  runSearch,
+	}
+
+	searchCmd.Flags().StringVar(&flagSearchSite, "site", "", "only search the site with this name")
+	searchCmd.Flags().IntVar(&flagSearchLimit, "limit", 10, "maximum results per site")
+	searchCmd.Flags().BoolVar(&flagSearchNoPrompt, "no-prompt", false, "only print the results")
+	searchCmd.Flags().BoolVar(&flagSearchJSON, "json", false, "print the results (with cover URLs) as JSON and exit")
+
+	rootCmd.AddCommand(searchCmd)
+}
+
+func runSearch(cmd *cobra.Command, args []string) error {
+	query := strings.Join(args, " ")
+
+	cfg, _, err := config.LoadMerged(config.Options{
+		IgnoreConfig: flagIgnoreConfig,
+		Debug:        flagDebug,
+	})
+	if err != nil {
+		return err
+	}
+
+	sites, err := config.LoadSearchSites(cfg)
+	if err != nil {
+		return err
+	}
+	if flagSearchSite != "" {
+		sites = filterSites(sites, flagSearchSite)
+	}
+	if len(sites) == 0 {
+		return fmt.Errorf("no search sites configured (add search_sites to the config or %s)", config.SearchSitesFile())
+	}
+
+	logSvc, err := newLogger(cfg)
+	if err != nil {
+		return err
+	}
+	_, scr, ctx, err := setupEnvironment(cfg, logSvc)
+	if err != nil {
+		return err
+	}
+
+	results := searchSites(ctx, scr.Fetch, sites, query, logSvc)
+	if flagSearchJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(results)
+	}
+
+	if len(results) == 0 {
+		fmt.Printf("No results for %q.\n", query)
+		return nil
+	}
+
+	for i, r := range results {
+		fmt.Printf("%3d) %s  [%s]\n     %s\n", i+1, r.Title, r.Site, r.URL)
+	}
+	fmt.Println()
+
+	if flagSearchNoPrompt {
+		return nil
+	}
+
+	return chooseSearchResult(cmd, cfg, results)
+}
+
+func filterSites(sites []config.SearchSite, name string) []config.SearchSite {
+	for _, s := range sites {
+		if strings.EqualFold(s.Name, name) {
+			return []config.SearchSite{s}
+		}
+	}
+
+	return nil
+}
+
+// searchSites queries all sites in parallel and keeps their results in
+// config order. A failing site is reported and skipped.
+func searchSites(ctx context.Context, fetch search.FetchFunc, sites []config.SearchSite, query string, logSvc *ui.Logger) []search.Result {
+	perSite := make([][]search.Result, len(sites))
+
+	var wg sync.WaitGroup
+	for i, site := range sites {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			res, err := search.Search(ctx, fetch, site, query)
+			if err != nil {
+				logSvc.Warn("Search failed", "site", site.Name, "err", err)
+				return
+			}
+			if flagSearchLimit > 0 && len(res) > flagSearchLimit {
+				res = res[:flagSearchLimit]
+			}
+			perSite[i] = res
+		}()
+	}
+	wg.Wait()
+
+	var out []search.Result
+	for _, res := range perSite {
+		out = append(out, res...)
+	}
+
+	return out
+}
+
+func chooseSearchResult(cmd *cobra.Command, cfg *config.Config, results []search.Result) error {
+	items := make([]string, len(results))
+	for i, r := range results {
+		items[i] = fmt.Sprintf("%s  [%s]", r.Title, r.Site)
+	}
+
+	pick := promptui.Select{Label: "Select a series", Items: items, Size: 15}
+	idx, _, err := pick.Run()
+	if err != nil {
+		return fmt.Errorf("selection cancelled")
+	}
+	chosen := results[idx]
+
+	action := promptui.Select{
+		Label: chosen.Title,
+		Items: []string{"Download all chapters now", "Create a config profile", "Print the URL"},
+	}
+	act, _, err := action.Run()
+	if err != nil {
+		return fmt.Errorf("selection cancelled")
+	}
+
+	switch act {
+	case 0:
+		// the profile's series name and chapter selection are for its own series
+		cfg.DefaultURL, cfg.SeriesName = chosen.URL, ""
+		cfg.ClearSelection()
+		return downloadSeries(cmd, cfg, "")
+	case 1:
+		return createProfileFor(cfg, chosen)
+	default:
+		fmt.Println(chosen.URL)
+		return nil
+	}
+}
+
+// createProfileFor saves a copy of the current config pointing at the
+// chosen series, without the chapter selection of the current profile.
+func createProfileFor(cfg *config.Config, r search.Result) error {
+	prompt := promptui.Prompt{Label: "Config label", Default: profileLabel(r.Title)}
+	label, err := prompt.Run()
+	if err != nil {
+		return fmt.Errorf("selection cancelled")
+	}
+	label = strings.TrimSpace(label)
+	if label == "" {
+		return fmt.Errorf("label cannot be empty")
+	}
+
+	cfgDir := config.ConfigsDir()
+	path := filepath.Join(cfgDir, label+".yaml")
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("a config named %q already exists", label)
+	}
+
+	profile := *cfg
+	profile.DefaultURL = r.URL
+	profile.SeriesName = ""
+	profile.ClearSelection()
+
+	if err := os.MkdirAll(cfgDir, 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := config.SaveYAML(&profile, path); err != nil {
+		return fmt.Errorf("failed to save YAML: %w", err)
+	}
+
+	fmt.Printf("Created new config: %s\n", path)
+	fmt.Printf("Run `mangad config switch %s` to use it.\n", label)
+
+	return nil
+}
+
+func profileLabel(title string) string {
+	var b strings.Builder
+	for _, f := range strings.FieldsFunc(title, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+	}) {
+		if b.Len() > 0 {
+			b.WriteByte('-')
+		}
+		b.WriteString(strings.ToLower(f))
+	}
+
+	return b.String()
+}
@@ -0,0 +1,206 @@
+package chapters
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/brogergvhs/mangad/internal/providers"
+)
+
+type Severity int
+
+const (
+	SeverityInfo Severity = iota
+	SeverityWarning
+	SeveritySevere
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityWarning:
+		return "warning"
+	case SeveritySevere:
+		return "severe"
+	default:
+		return "info"
+	}
+}
+
+// Anomaly is something odd about a parsed chapter list, usually a sign that
+// the label heuristics picked up the wrong links or numbers.
+type Anomaly struct {
+	Severity Severity
+	Kind     string // missing, jump, duplicate, zero, order
+	Message  string
+}
+
+const (
+	// maxGap is the largest run of missing chapters still reported as a
+	// gap; anything wider is reported as a jump.
+	maxGap = 9
+	// severeGap marks jumps too wide to be missing uploads (e.g. 41 → 410).
+	severeGap = 50
+	// orderShare is the share of neighbours that must agree for the site
+	// order to count as ascending or descending.
+	orderShare = 0.8
+)
+
+// Analyze checks a chapter list (before duplicate resolution) for gaps,
+// jumps, duplicate labels, chapters numbered 0 and the order the site
+// listed them in. The list is expected to be sorted.
+func Analyze(all []Chapter) []Anomaly {
+	var out []Anomaly
+
+	var numbered []Chapter
+	for _, ch := range all {
+		if ch.Kind == providers.KindRegular || ch.Kind == "" {
+			numbered = append(numbered, ch)
+		}
+	}
+
+	out = append(out, checkZero(numbered)...)
+	out = append(out, checkGaps(numbered)...)
+	out = append(out, checkDuplicates(all)...)
+	out = append(out, checkOrder(numbered)...)
+
+	return out
+}
+
+func HasSevere(list []Anomaly) bool {
+	for _, a := range list {
+		if a.Severity == SeveritySevere {
+			return true
+		}
+	}
+
+	return false
+}
+
+func checkZero(numbered []Chapter) []Anomaly {
+	n := 0
+	for _, ch := range numbered {
+		if ch.Number == 0 {
+			n++
+		}
+	}
+
+	switch {
+	case n == 1:
+		return []Anomaly{{SeverityInfo, "zero", "one chapter is numbered 0 (fine for a prologue)"}}
+	case n > 1:
+		return []Anomaly{{SeveritySevere, "zero", fmt.Sprintf("%d chapters parsed to number 0; the chapter labels were probably not recognised", n)}}
+	}
+
+	return nil
+}
+
+// checkGaps compares whole chapter numbers of neighbours. Steps back (a new
+// volume restarting its numbering) are ignored.
+func checkGaps(numbered []Chapter) []Anomaly {
+	var out []Anomaly
+
+	for i := 1; i < len(numbered); i++ {
+		prev := math.Floor(numbered[i-1].Number)
+		cur := math.Floor(numbered[i].Number)
+		missing := int(cur-prev) - 1
+		if missing <= 0 {
+			continue
+		}
+
+		from, to := int(prev)+1, int(cur)-1
+		span := fmt.Sprintf("%d", from)
+		if to > from {
+			span = fmt.Sprintf("%d-%d", from, to)
+		}
+
+		switch {
+		case missing <= maxGap:
+			out = append(out, Anomaly{SeverityWarning, "missing",
+				fmt.Sprintf("missing chapter %s (between %s and %s)", span, numbered[i-1].Label, numbered[i].Label)})
+		case missing < severeGap:
+			out = append(out, Anomaly{SeverityWarning, "jump",
+				fmt.Sprintf("suspicious jump from %s to %s", numbered[i-1].Label, numbered[i].Label)})
+		default:
+			out = append(out, Anomaly{SeveritySevere, "jump",
+				fmt.Sprintf("suspicious jump from %s to %s; %s is probably not a chapter number", numbered[i-1].Label, numbered[i].Label, numbered[i].Label)})
+		}
+	}
+
+	return out
+}
+
+// checkDuplicates reports chapters listed more than once, keyed like
+// ResolveDuplicates so separate extras don't count. Copies from
+// different groups or languages are expected; copies that can't be told
+// apart usually mean two different chapters parsed to the same label.
+func checkDuplicates(all []Chapter) []Anomaly {
+	type seen struct {
+		label   string
+		sources map[string]int
+		count   int
+	}
+
+	byKey := map[string]*seen{}
+	var order []string
+	for _, ch := range all {
+		key := duplicateKey(ch)
+		s, ok := byKey[key]
+		if !ok {
+			s = &seen{label: ch.Label, sources: map[string]int{}}
+			byKey[key] = s
+			order = append(order, key)
+		}
+		s.count++
+		s.sources[ch.Group+"|"+ch.Language]++
+	}
+
+	var out []Anomaly
+	for _, key := range order {
+		s := byKey[key]
+		if s.count < 2 {
+			continue
+		}
+
+		if len(s.sources) == s.count {
+			out = append(out, Anomaly{SeverityInfo, "duplicate",
+				fmt.Sprintf("chapter %s is listed %d times by different groups or languages", s.label, s.count)})
+			continue
+		}
+
+		out = append(out, Anomaly{SeverityWarning, "duplicate",
+			fmt.Sprintf("chapter %s is listed %d times with no way to tell the copies apart", s.label, s.count)})
+	}
+
+	return out
+}
+
+// checkOrder compares the page order with the sorted order. Newest-first
+// listings are normal; a mix of both usually means the numbers are wrong.
+func checkOrder(numbered []Chapter) []Anomaly {
+	if len(numbered) < 3 {
+		return nil
+	}
+
+	up, down := 0, 0
+	for i := 1; i < len(numbered); i++ {
+		switch d := numbered[i].SourceIndex - numbered[i-1].SourceIndex; {
+		case d > 0:
+			up++
+		case d < 0:
+			down++
+		}
+	}
+
+	total := float64(len(numbered) - 1)
+	switch {
+	case up == 0 && down == 0:
+		return nil
+	case float64(up)/total >= orderShare:
+		return nil
+	case float64(down)/total >= orderShare:
+		return []Anomaly{{SeverityInfo, "order", "site lists chapters newest first; sorted oldest first"}}
+	}
+
+	return []Anomaly{{SeverityWarning, "order",
+		fmt.Sprintf("site order disagrees with parsed numbers (%d steps forward, %d back)", up, down)}}
+}
@@ -1,6 +1,7 @@
 package chapters
 
 import (
+	"fmt"
 	"path/filepath"
 	"regexp"
 	"strings"
@@ -54,12 +55,54 @@ func (c Chapter) baseName() string {
 		title = trimmed
 	}
 
+	if c.Volume > 0 {
+		lbl = fmt.Sprintf("vol%d_%s", c.Volume, lbl)
+	}
+
 	if title != "" {
 		return lbl + "_" + title
 	}
 	return lbl
 }
 
+// DisplayLabel renders the chapter for humans, e.g. "Vol.3 Ch.27" or "Extra 2".
+func (c Chapter) DisplayLabel() string {
+	var lbl string
+	switch c.Kind {
+	case providers.KindRegular, "":
+		lbl = "Ch." + c.Label
+	default:
+		lbl = strings.ToUpper(string(c.Kind[:1])) + string(c.Kind[1:])
+		if c.Number > 0 {
+			lbl += " " + providers.FormatNumber(c.Number)
+		}
+	}
+
+	if c.Volume > 0 {
+		return fmt.Sprintf("Vol.%d %s", c.Volume, lbl)
+	}
+	return lbl
+}
+
+// FileName is the CBZ name used without a name template, such as
+// "Vol.3 Ch.12.5 - (Part 2) — Rebirth.cbz", cleaned with p. PolicySlug
+// gives the old vol3_12_5_part_2_rebirth.cbz names.
+func (c Chapter) FileName(p Policy) string {
+	if p == PolicySlug {
+		return c.OutputCBZ()
+	}
+
+	name := c.DisplayLabel()
+	if t := titleName(c.Title); t != "" {
+		name += " - " + t
+	}
+	if f := p.CleanFile(slashes.Replace(name), ".cbz"); f != "" {
+		return f
+	}
+
+	return c.OutputCBZ()
+}
+
 func (c Chapter) FolderName() string {
 	return c.baseName() + "_tmp"
 }
@@ -0,0 +1,69 @@
+package chapters
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/brogergvhs/mangad/internal/providers"
+)
+
+// comicInfo is the subset of the ComicInfo 2.0 schema mangad knows.
+type comicInfo struct {
+	XMLName         xml.Name `xml:"ComicInfo"`
+	XSI             string   `xml:"xmlns:xsi,attr"`
+	XSD             string   `xml:"xmlns:xsd,attr"`
+	Title           string   `xml:"Title,omitempty"`
+	Series          string   `xml:"Series,omitempty"`
+	Number          string   `xml:"Number,omitempty"`
+	Volume          int      `xml:"Volume,omitempty"`
+	Notes           string   `xml:"Notes,omitempty"`
+	Year            int      `xml:"Year,omitempty"`
+	Month           int      `xml:"Month,omitempty"`
+	Day             int      `xml:"Day,omitempty"`
+	Web             string   `xml:"Web,omitempty"`
+	PageCount       int      `xml:"PageCount"`
+	LanguageISO     string   `xml:"LanguageISO,omitempty"`
+	ScanInformation string   `xml:"ScanInformation,omitempty"`
+}
+
+// ComicInfo renders the chapter's ComicInfo.xml. pages is the number of
+// pages in the archive; missing lists the 1-based page numbers that could
+// not be downloaded, which are noted so the gaps in the page numbering are
+// explained.
+func (c Chapter) ComicInfo(series string, pages int, missing []int) ([]byte, error) {
+	ci := comicInfo{
+		XSI:             "http://www.w3.org/2001/XMLSchema-instance",
+		XSD:             "http://www.w3.org/2001/XMLSchema",
+		Title:           strings.TrimSpace(c.Title),
+		Series:          series,
+		Number:          c.Label,
+		Volume:          c.Volume,
+		Web:             c.URL,
+		PageCount:       pages,
+		LanguageISO:     c.Language,
+		ScanInformation: c.Group,
+	}
+	if c.Number > 0 {
+		ci.Number = providers.FormatNumber(c.Number)
+	}
+	if !c.Date.IsZero() {
+		ci.Year, ci.Month, ci.Day = c.Date.Year(), int(c.Date.Month()), c.Date.Day()
+	}
+	if len(missing) > 0 {
+		nums := make([]string, len(missing))
+		for i, p := range missing {
+			nums[i] = strconv.Itoa(p)
+		}
+		ci.Notes = fmt.Sprintf("Missing pages: %s of %d (skipped, could not be downloaded).",
+			strings.Join(nums, ", "), pages+len(missing))
+	}
+
+	out, err := xml.MarshalIndent(ci, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte(xml.Header), out...), nil
+}
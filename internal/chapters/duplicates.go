@@ -0,0 +1,165 @@
+package chapters
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/brogergvhs/mangad/internal/providers"
+)
+
+// Preferences steer which copy of a chapter is kept when a site lists the
+// same chapter more than once (one per scanlation group or language).
+type Preferences struct {
+	Groups    []string
+	Languages []string
+}
+
+// Resolution records a duplicate that was collapsed into one chapter.
+type Resolution struct {
+	Chosen  Chapter
+	Dropped []Chapter
+	Reason  string
+}
+
+// ResolveDuplicates keeps one chapter per duplicateKey. Copies are ranked by
+// preferred language, then preferred group, then release date. Without
+// dates the order the site listed them in decides (sites list the most
+// recent upload first).
+func ResolveDuplicates(all []Chapter, prefs Preferences) ([]Chapter, []Resolution) {
+	type bucket struct {
+		first int
+		items []Chapter
+	}
+
+	buckets := map[string]*bucket{}
+	order := make([]string, 0, len(all))
+
+	for i, ch := range all {
+		key := duplicateKey(ch)
+		b, ok := buckets[key]
+		if !ok {
+			b = &bucket{first: i}
+			buckets[key] = b
+			order = append(order, key)
+		}
+		b.items = append(b.items, ch)
+	}
+
+	out := make([]Chapter, 0, len(order))
+	var res []Resolution
+
+	for _, key := range order {
+		b := buckets[key]
+		if len(b.items) == 1 {
+			out = append(out, b.items[0])
+			continue
+		}
+
+		best := 0
+		for i := 1; i < len(b.items); i++ {
+			if prefs.better(b.items[i], b.items[best]) {
+				best = i
+			}
+		}
+
+		chosen := b.items[best]
+		dropped := make([]Chapter, 0, len(b.items)-1)
+		for i, ch := range b.items {
+			if i != best {
+				dropped = append(dropped, ch)
+			}
+		}
+
+		out = append(out, chosen)
+		res = append(res, Resolution{
+			Chosen:  chosen,
+			Dropped: dropped,
+			Reason:  prefs.reason(chosen, dropped),
+		})
+	}
+
+	return out, res
+}
+
+// duplicateKey is the same for all copies of one chapter: its volume and
+// label. Unnumbered extras and specials (side stories all labeled "extra")
+// are only copies when they share a URL; a series has a single prologue,
+// epilogue or oneshot, so those collapse like numbered chapters.
+func duplicateKey(ch Chapter) string {
+	key := fmt.Sprintf("%d|%s", ch.Volume, strings.ToLower(ch.Label))
+	if !ch.IsNumbered() && (ch.Kind == providers.KindExtra || ch.Kind == providers.KindSpecial) {
+		key += "|" + ch.URL
+	}
+
+	return key
+}
+
+// better reports whether a should be preferred over b. Ties keep b, which
+// was listed earlier.
+func (p Preferences) better(a, b Chapter) bool {
+	if ra, rb := p.langRank(a.Language), p.langRank(b.Language); ra != rb {
+		return ra < rb
+	}
+	if ra, rb := p.groupRank(a.Group), p.groupRank(b.Group); ra != rb {
+		return ra < rb
+	}
+	if !a.Date.IsZero() && !b.Date.IsZero() {
+		return a.Date.After(b.Date)
+	}
+
+	return false
+}
+
+func (p Preferences) reason(chosen Chapter, dropped []Chapter) string {
+	for _, d := range dropped {
+		if p.langRank(chosen.Language) < p.langRank(d.Language) {
+			return "preferred language " + chosen.Language
+		}
+	}
+	for _, d := range dropped {
+		if p.groupRank(chosen.Group) < p.groupRank(d.Group) {
+			return "preferred group " + chosen.Group
+		}
+	}
+
+	return "most recent upload"
+}
+
+// langRank compares languages the way the scraper stores them, so a
+// preference like "English" or "pt_BR" matches "en" and "pt-br".
+func (p Preferences) langRank(v string) int {
+	return rank(p.Languages, v, providers.NormalizeLang)
+}
+
+func (p Preferences) groupRank(v string) int {
+	return rank(p.Groups, v, strings.TrimSpace)
+}
+
+// rank returns the position of v in prefs (case-insensitive, after norm),
+// or len(prefs) when v isn't preferred at all.
+func rank(prefs []string, v string, norm func(string) string) int {
+	v = norm(v)
+	for i, p := range prefs {
+		if v != "" && strings.EqualFold(norm(p), v) {
+			return i
+		}
+	}
+
+	return len(prefs)
+}
+
+// Source describes where a chapter copy came from, e.g. "GroupX, en".
+func (c Chapter) Source() string {
+	parts := make([]string, 0, 2)
+	if c.Group != "" {
+		parts = append(parts, c.Group)
+	}
+	if c.Language != "" {
+		parts = append(parts, c.Language)
+	}
+	if len(parts) == 0 {
+		return "unknown source"
+	}
+
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,317 @@
+package chapters
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/brogergvhs/mangad/internal/providers"
+)
+
+var ErrInvalidSelection = errors.New("invalid chapter selection")
+
+// Selection is a parsed label-based selection expression such as
+// "10-20.5,25,!13,vol:3,extras,latest:5". Terms are comma separated; the
+// chapters matching any term are selected (all chapters when there are only
+// exclusions), then every term prefixed with "!" is removed.
+//
+// Terms:
+//
+//	25, 12.5, extra-2   a single chapter label (as for --chapter)
+//	10-20.5, 100-, -9   a range of regular chapter numbers, open ends allowed
+//	vol:3, vol:3-5      volumes
+//	extras, kind:extra  chapters of a kind (regular, extra, special, ...)
+//	latest:N            the N highest-numbered regular chapters
+//	all, *              everything
+type Selection struct {
+	include []term
+	exclude []term
+}
+
+// term reports which chapters of the (sorted) list it selects.
+type term func(all []Chapter) []bool
+
+// ParseSelection parses a selection expression.
+func ParseSelection(expr string) (Selection, error) {
+	var sel Selection
+
+	for raw := range strings.SplitSeq(expr, ",") {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+
+		neg := strings.HasPrefix(raw, "!")
+		body := strings.TrimSpace(strings.TrimPrefix(raw, "!"))
+		if body == "" {
+			// a lone "!" would otherwise exclude nothing and select everything
+			return Selection{}, fmt.Errorf("%w: %q", ErrInvalidSelection, raw)
+		}
+		t, err := parseTerm(body)
+		if err != nil {
+			return Selection{}, err
+		}
+
+		if neg {
+			sel.exclude = append(sel.exclude, t)
+		} else {
+			sel.include = append(sel.include, t)
+		}
+	}
+
+	if len(sel.include) == 0 && len(sel.exclude) == 0 {
+		return Selection{}, fmt.Errorf("%w: empty expression", ErrInvalidSelection)
+	}
+
+	return sel, nil
+}
+
+// Apply returns the selected chapters in list order.
+func (s Selection) Apply(all []Chapter) []Chapter {
+	keep := make([]bool, len(all))
+	if len(s.include) == 0 {
+		for i := range keep {
+			keep[i] = true
+		}
+	}
+
+	for _, t := range s.include {
+		for i, ok := range t(all) {
+			keep[i] = keep[i] || ok
+		}
+	}
+	for _, t := range s.exclude {
+		for i, ok := range t(all) {
+			keep[i] = keep[i] && !ok
+		}
+	}
+
+	return buildResult(all, keep)
+}
+
+func parseTerm(s string) (term, error) {
+	lower := strings.ToLower(s)
+
+	switch {
+	case lower == "all" || lower == "*":
+		return matchEach(func(Chapter) bool { return true }), nil
+
+	case strings.HasPrefix(lower, "vol:"):
+		lo, hi, err := parseBounds(strings.TrimPrefix(lower, "vol:"))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidSelection, s)
+		}
+		return matchEach(func(ch Chapter) bool {
+			return ch.Volume > 0 && float64(ch.Volume) >= lo && float64(ch.Volume) <= hi
+		}), nil
+
+	case strings.HasPrefix(lower, "latest:"):
+		n, err := strconv.Atoi(strings.TrimPrefix(lower, "latest:"))
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidSelection, s)
+		}
+		return latest(n), nil
+
+	case strings.HasPrefix(lower, "kind:"):
+		k, ok := kindByName(strings.TrimPrefix(lower, "kind:"))
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown kind in %q", ErrInvalidSelection, s)
+		}
+		return matchEach(func(ch Chapter) bool { return chapterKind(ch) == k }), nil
+	}
+
+	if k, ok := kindByName(lower); ok {
+		return matchEach(func(ch Chapter) bool { return chapterKind(ch) == k }), nil
+	}
+
+	if strings.Contains(lower, "-") {
+		if lo, hi, err := parseBounds(lower); err == nil {
+			return matchEach(func(ch Chapter) bool {
+				return chapterKind(ch) == providers.KindRegular && ch.IsNumbered() &&
+					ch.Number >= lo && ch.Number <= hi
+			}), nil
+		}
+	}
+
+	// a single label; no match is not an error, the site may not have it yet
+	return func(all []Chapter) []bool {
+		hits := make([]bool, len(all))
+		exact := false
+		for i, ch := range all {
+			if strings.EqualFold(ch.Label, s) {
+				hits[i], exact = true, true
+			}
+		}
+		if exact {
+			return hits
+		}
+		for i, ch := range all {
+			hits[i] = ch.MatchesLabel(s)
+		}
+		return hits
+	}, nil
+}
+
+func matchEach(fn func(Chapter) bool) term {
+	return func(all []Chapter) []bool {
+		hits := make([]bool, len(all))
+		for i, ch := range all {
+			hits[i] = fn(ch)
+		}
+		return hits
+	}
+}
+
+func latest(n int) term {
+	return func(all []Chapter) []bool {
+		var idx []int
+		for i, ch := range all {
+			if chapterKind(ch) == providers.KindRegular && ch.IsNumbered() {
+				idx = append(idx, i)
+			}
+		}
+		sort.SliceStable(idx, func(a, b int) bool { return all[idx[a]].Number > all[idx[b]].Number })
+
+		hits := make([]bool, len(all))
+		for _, i := range idx[:min(n, len(idx))] {
+			hits[i] = true
+		}
+		return hits
+	}
+}
+
+// parseBounds parses "3", "3-5", "100-" and "-9" into inclusive bounds.
+func parseBounds(s string) (lo, hi float64, err error) {
+	a, b, isRange := strings.Cut(s, "-")
+	a, b = strings.TrimSpace(a), strings.TrimSpace(b)
+
+	if !isRange {
+		v, err := strconv.ParseFloat(a, 64)
+		return v, v, err
+	}
+	if a == "" && b == "" {
+		return 0, 0, ErrInvalidSelection
+	}
+
+	lo, hi = math.Inf(-1), math.Inf(1)
+	if a != "" {
+		if lo, err = strconv.ParseFloat(a, 64); err != nil {
+			return 0, 0, err
+		}
+	}
+	if b != "" {
+		if hi, err = strconv.ParseFloat(b, 64); err != nil {
+			return 0, 0, err
+		}
+	}
+	if lo > hi {
+		return 0, 0, ErrInvalidSelection
+	}
+
+	return lo, hi, nil
+}
+
+// kindByName accepts kind names in singular or plural ("extras").
+func kindByName(s string) (providers.ChapterKind, bool) {
+	s = strings.TrimSuffix(s, "s")
+	for _, k := range []providers.ChapterKind{
+		providers.KindRegular, providers.KindExtra, providers.KindSpecial,
+		providers.KindOneshot, providers.KindPrologue, providers.KindEpilogue,
+	} {
+		if s == string(k) {
+			return k, true
+		}
+	}
+
+	return "", false
+}
+
+func chapterKind(ch Chapter) providers.ChapterKind {
+	if ch.Kind == "" {
+		return providers.KindRegular
+	}
+
+	return ch.Kind
+}
+
+// Expression renders selected (a subset of all) as an expression that
+// ParseSelection accepts, collapsing runs of three or more consecutive
+// regular chapters into ranges. Ranges are only used when the expression
+// selects exactly the same chapters again. It fails when no expression
+// does, e.g. when one of several extras sharing a label was picked.
+func Expression(all []Chapter, selected []Chapter) (string, error) {
+	chosen := make(map[string]bool, len(selected))
+	for _, ch := range selected {
+		chosen[ch.URL] = true
+	}
+
+	var terms, plain []string
+	var run []Chapter
+
+	flush := func() {
+		if len(run) >= 3 {
+			terms = append(terms, providers.FormatNumber(run[0].Number)+"-"+providers.FormatNumber(run[len(run)-1].Number))
+		} else {
+			for _, ch := range run {
+				terms = append(terms, labelTerm(ch))
+			}
+		}
+		run = run[:0]
+	}
+
+	for _, ch := range all {
+		if !chosen[ch.URL] {
+			flush()
+			continue
+		}
+		plain = append(plain, labelTerm(ch))
+
+		rangeable := chapterKind(ch) == providers.KindRegular && ch.IsNumbered()
+		if !rangeable || len(run) > 0 && ch.Number <= run[len(run)-1].Number {
+			flush()
+		}
+		if rangeable {
+			run = append(run, ch)
+		} else {
+			terms = append(terms, labelTerm(ch))
+		}
+	}
+	flush()
+
+	for _, expr := range []string{strings.Join(terms, ","), strings.Join(plain, ",")} {
+		if sel, err := ParseSelection(expr); err == nil && sameChapters(sel.Apply(all), selected) {
+			return expr, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w: the picked chapters share labels with chapters that weren't picked", ErrInvalidSelection)
+}
+
+func labelTerm(ch Chapter) string {
+	if ch.Volume > 0 {
+		return fmt.Sprintf("vol%d/%s", ch.Volume, ch.Label)
+	}
+
+	return ch.Label
+}
+
+func sameChapters(a, b []Chapter) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	urls := make(map[string]bool, len(a))
+	for _, ch := range a {
+		urls[ch.URL] = true
+	}
+	for _, ch := range b {
+		if !urls[ch.URL] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,163 @@
+package chapters
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/brogergvhs/mangad/internal/providers"
+)
+
+// exprList is a small series: a prologue, three volumes of regular
+// chapters (with a 5.5), two unnumbered extras and a numbered one.
+func exprList() []Chapter {
+	ch := func(url, label string, number float64, volume int, kind providers.ChapterKind) Chapter {
+		return Chapter{providers.Chapter{URL: url, Label: label, Number: number, Volume: volume, Kind: kind}}
+	}
+
+	return []Chapter{
+		ch("prologue", "prologue", 0, 0, providers.KindPrologue),
+		ch("1", "1", 1, 1, providers.KindRegular),
+		ch("2", "2", 2, 1, providers.KindRegular),
+		ch("3", "3", 3, 1, providers.KindRegular),
+		ch("4", "4", 4, 2, providers.KindRegular),
+		ch("5", "5", 5, 2, providers.KindRegular),
+		ch("5.5", "5.5", 5.5, 2, providers.KindRegular),
+		ch("6", "6", 6, 3, providers.KindRegular),
+		ch("extra-a", "extra", 0, 0, providers.KindExtra),
+		ch("extra-b", "extra", 0, 0, providers.KindExtra),
+		ch("extra-2", "extra-2", 2, 0, providers.KindExtra),
+	}
+}
+
+func urls(list []Chapter) []string {
+	out := make([]string, 0, len(list))
+	for _, ch := range list {
+		out = append(out, ch.URL)
+	}
+
+	return out
+}
+
+func pick(all []Chapter, want ...string) []Chapter {
+	var out []Chapter
+	for _, ch := range all {
+		for _, u := range want {
+			if ch.URL == u {
+				out = append(out, ch)
+			}
+		}
+	}
+
+	return out
+}
+
+func TestParseSelection(t *testing.T) {
+	all := exprList()
+	everything := urls(all)
+
+	for _, tc := range []struct {
+		expr string
+		want []string
+	}{
+		{"3", []string{"3"}},
+		{"5.5", []string{"5.5"}},
+		{"5.50", []string{"5.5"}},
+		{"ch 4", []string{"4"}},
+		{"vol2/5", []string{"5"}},
+		{"1-3", []string{"1", "2", "3"}},
+		{"2-5.5", []string{"2", "3", "4", "5", "5.5"}},
+		{"5-", []string{"5", "5.5", "6"}},
+		{"-2", []string{"1", "2"}},
+		{" 1 , 6 ", []string{"1", "6"}},
+		{"vol:2", []string{"4", "5", "5.5"}},
+		{"vol:2-3", []string{"4", "5", "5.5", "6"}},
+		{"vol:2-", []string{"4", "5", "5.5", "6"}},
+		{"extras", []string{"extra-a", "extra-b", "extra-2"}},
+		{"kind:extra", []string{"extra-a", "extra-b", "extra-2"}},
+		{"kind:prologue", []string{"prologue"}},
+		{"regular", []string{"1", "2", "3", "4", "5", "5.5", "6"}},
+		{"extra", []string{"extra-a", "extra-b", "extra-2"}}, // a kind name, not the label
+		{"extra-2", []string{"extra-2"}},
+		{"latest:2", []string{"5.5", "6"}},
+		{"latest:100", []string{"1", "2", "3", "4", "5", "5.5", "6"}},
+		{"all", everything},
+		{"*", everything},
+		{"!extras", []string{"prologue", "1", "2", "3", "4", "5", "5.5", "6"}},
+		{"!vol:1,!extras,!prologue", []string{"4", "5", "5.5", "6"}},
+		{"1-6,!5.5,!vol:1", []string{"4", "5", "6"}},
+		{"vol:3,extra-2", []string{"6", "extra-2"}},
+		{"latest:3,!5.5", []string{"5", "6"}},
+		{"99", []string{}},
+	} {
+		sel, err := ParseSelection(tc.expr)
+		if err != nil {
+			t.Errorf("ParseSelection(%q): %v", tc.expr, err)
+			continue
+		}
+		if got := urls(sel.Apply(all)); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("ParseSelection(%q) selects %q, want %q", tc.expr, got, tc.want)
+		}
+	}
+}
+
+func TestParseSelectionErrors(t *testing.T) {
+	for _, expr := range []string{"", " , ", "!", "vol:", "vol:x", "vol:5-2", "latest:", "latest:0", "latest:x", "kind:sidequest", "1,!"} {
+		_, err := ParseSelection(expr)
+		if !errors.Is(err, ErrInvalidSelection) {
+			t.Errorf("ParseSelection(%q) error = %v, want ErrInvalidSelection", expr, err)
+		}
+	}
+}
+
+func TestExpression(t *testing.T) {
+	all := exprList()
+
+	for _, tc := range []struct {
+		picked []string
+		want   string
+	}{
+		{[]string{"1", "2", "3", "4"}, "1-4"},
+		{[]string{"4", "5", "5.5", "6"}, "4-6"},
+		{[]string{"1", "2"}, "vol1/1,vol1/2"},
+		{[]string{"4", "5", "6"}, "vol2/4,vol2/5,vol3/6"},
+		{[]string{"prologue", "1", "2", "3"}, "prologue,1-3"},
+		{[]string{"1", "2", "3", "extra-2"}, "1-3,extra-2"},
+	} {
+		picked := pick(all, tc.picked...)
+
+		expr, err := Expression(all, picked)
+		if err != nil {
+			t.Errorf("Expression(%q): %v", tc.picked, err)
+			continue
+		}
+		if expr != tc.want {
+			t.Errorf("Expression(%q) = %q, want %q", tc.picked, expr, tc.want)
+		}
+
+		// the saved expression must select the same chapters on the next run
+		sel, err := ParseSelection(expr)
+		if err != nil {
+			t.Errorf("ParseSelection(%q): %v", expr, err)
+			continue
+		}
+		if got := urls(sel.Apply(all)); !reflect.DeepEqual(got, tc.picked) {
+			t.Errorf("Expression(%q) = %q selects %q again", tc.picked, expr, got)
+		}
+	}
+}
+
+func TestExpressionAmbiguous(t *testing.T) {
+	all := exprList()
+
+	for _, picked := range [][]string{
+		{"extra-a", "1"},       // one of two extras labeled "extra"
+		{"extra-a", "extra-b"}, // "extra" is also the kind, so it takes extra-2 along
+	} {
+		_, err := Expression(all, pick(all, picked...))
+		if !errors.Is(err, ErrInvalidSelection) || !strings.Contains(err.Error(), "share labels") {
+			t.Errorf("Expression(%q): err = %v, want a shared label error", picked, err)
+		}
+	}
+}
@@ -0,0 +1,275 @@
+package chapters
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+
+	"github.com/brogergvhs/mangad/internal/providers"
+)
+
+// NameData is what a name template sees for one chapter.
+type NameData struct {
+	Series   string
+	Volume   int    // 0 when unknown
+	Number   string // "27.5"
+	Padded   string // number with three integer digits, "027.5"
+	Label    string // "27.5", "extra-2"
+	Kind     string
+	Special  int // position among the series' non-regular chapters, from 1; 0 for regular chapters
+	Title    string
+	Name     string // title without a leading "Chapter 12:" or "Vol. 3 Ch. 12 -"
+	Group    string
+	Language string
+	Date     time.Time // zero when unknown
+}
+
+// LibraryTemplate names chapters inside the series folder the way Komga and
+// Kavita parse them: "Series Vol.03 Ch.027.5 - Title.cbz", with non-regular
+// chapters as specials ("Series SP02 Extra.cbz").
+const LibraryTemplate = `{{.Series}}{{if .Volume}} Vol.{{pad 2 .Volume}}{{end}}` +
+	`{{if or (eq .Kind "regular") (eq .Kind "")}} Ch.{{.Padded}}{{else}} SP{{pad 2 .Special}} {{title .Kind}}{{end}}` +
+	`{{if .Name}} - {{.Name}}{{end}}`
+
+// reTitleNumber matches the chapter numbering many sites repeat at the start
+// of chapter titles.
+var reTitleNumber = regexp.MustCompile(`(?i)^(?:vol(?:ume)?\.?\s*\d+\s*[,:\-–—]?\s*)?(?:ch(?:apter)?\.?|episode|ep\.?|#)\s*\d+(?:[.\-]\d+)?\s*[:\-–—.]?\s*`)
+
+// slashes replaces path separators in template values.
+var slashes = strings.NewReplacer("/", "_", "\\", "_")
+
+// nameFuncs are the functions available in name templates.
+var nameFuncs = template.FuncMap{
+	"pad":   pad,
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+	"title": titleCase,
+	"slug":  sanitize,
+	"trim":  strings.TrimSpace,
+	"date": func(layout string, t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format(layout)
+	},
+}
+
+// Namer turns chapters into CBZ paths relative to the output folder, from a
+// text/template such as
+//
+//	{{.Series}}/{{if .Volume}}Vol. {{pad 2 .Volume}}/{{end}}{{.Series}} - Ch. {{.Padded}}{{if .Title}} - {{.Title}}{{end}}
+//
+// "/" in the result separates folders, and every folder and file name is
+// cleaned with the policy. Without a template Chapter.FileName is used.
+type Namer struct {
+	tmpl     *template.Template
+	series   string
+	policy   Policy
+	paths    map[string]string // chapter URL to path
+	specials map[string]int    // chapter URL to NameData.Special
+}
+
+// Collision is a name several chapters ended up with; all but the first are
+// renamed.
+type Collision struct {
+	Path     string
+	Chapters []string // labels, the first keeps Path
+	Renamed  []string // new paths of the others
+}
+
+func NewNamer(tmpl, series string, policy Policy) (*Namer, error) {
+	n := &Namer{series: series, policy: policy, paths: map[string]string{}}
+	if strings.TrimSpace(tmpl) == "" {
+		return n, nil
+	}
+
+	t, err := template.New("name").Funcs(nameFuncs).Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return nil, fmt.Errorf("invalid name template: %w", err)
+	}
+	n.tmpl = t
+
+	// catch unknown fields and bad function calls up front
+	sample := Chapter{Chapter: providers.Chapter{Label: "1", Number: 1, Title: "Sample", Kind: providers.KindRegular}}
+	if _, err := n.render(sample); err != nil {
+		return nil, err
+	}
+
+	return n, nil
+}
+
+// Plan names all chapters of a series at once, so that chapters whose names
+// collide are told apart the same way on every run, no matter which of them
+// are selected. Later chapters get the group or a counter appended.
+func (n *Namer) Plan(all []Chapter) []Collision {
+	n.paths = make(map[string]string, len(all))
+	n.specials = map[string]int{}
+	for _, ch := range all {
+		if ch.Kind != providers.KindRegular && ch.Kind != "" {
+			n.specials[ch.URL] = len(n.specials) + 1
+		}
+	}
+
+	taken := map[string]bool{}
+	byKey := map[string]*Collision{}
+	var out []*Collision
+
+	for _, ch := range all {
+		p := n.name(ch)
+		key := strings.ToLower(p)
+		if !taken[key] {
+			taken[key] = true
+			n.paths[ch.URL] = p
+			byKey[key] = &Collision{Path: p, Chapters: []string{ch.Label}}
+			continue
+		}
+
+		c := byKey[key]
+		if len(c.Chapters) == 1 {
+			out = append(out, c)
+		}
+
+		alt := ""
+		if ch.Group != "" {
+			alt = n.withSuffix(p, " ["+ch.Group+"]")
+		}
+		for i := 2; alt == "" || taken[strings.ToLower(alt)]; i++ {
+			alt = n.withSuffix(p, fmt.Sprintf(" (%d)", i))
+		}
+		taken[strings.ToLower(alt)] = true
+		n.paths[ch.URL] = alt
+
+		c.Chapters = append(c.Chapters, ch.Label)
+		c.Renamed = append(c.Renamed, alt)
+	}
+
+	collisions := make([]Collision, len(out))
+	for i, c := range out {
+		collisions[i] = *c
+	}
+
+	return collisions
+}
+
+// Series is the series name chapters are named with.
+func (n *Namer) Series() string {
+	return n.series
+}
+
+// SeriesFolder is the series name cleaned the way templates clean it, for
+// the folder {{.Series}} creates.
+func (n *Namer) SeriesFolder() string {
+	return n.policy.Clean(slashes.Replace(n.series))
+}
+
+// Path is the chapter's CBZ path relative to the output folder.
+func (n *Namer) Path(ch Chapter) string {
+	if p, ok := n.paths[ch.URL]; ok {
+		return p
+	}
+
+	return n.name(ch)
+}
+
+func (n *Namer) name(ch Chapter) string {
+	if n.tmpl == nil {
+		return ch.FileName(n.policy)
+	}
+
+	p, err := n.render(ch)
+	if err != nil || p == "" {
+		return ch.FileName(n.policy)
+	}
+
+	return p
+}
+
+func (n *Namer) render(ch Chapter) (string, error) {
+	var b strings.Builder
+	if err := n.tmpl.Execute(&b, n.data(ch)); err != nil {
+		return "", fmt.Errorf("invalid name template: %w", err)
+	}
+
+	return n.policy.cleanPath(b.String(), ".cbz"), nil
+}
+
+// data fills in the template fields. Slashes in values are replaced, so
+// only the template itself creates folders.
+func (n *Namer) data(ch Chapter) NameData {
+	num := providers.FormatNumber(ch.Number)
+	field := slashes.Replace
+
+	return NameData{
+		Series:   field(n.series),
+		Volume:   ch.Volume,
+		Number:   num,
+		Padded:   pad(3, num),
+		Label:    field(ch.Label),
+		Kind:     string(ch.Kind),
+		Special:  n.specials[ch.URL],
+		Title:    field(strings.TrimSpace(ch.Title)),
+		Name:     field(titleName(ch.Title)),
+		Group:    field(ch.Group),
+		Language: field(ch.Language),
+		Date:     ch.Date,
+	}
+}
+
+// pad zero-pads the integer part of a number to width digits: pad 3 27.5
+// gives "027.5". Values that aren't numbers are returned unchanged.
+func pad(width int, v any) string {
+	var s string
+	switch x := v.(type) {
+	case int:
+		s = strconv.Itoa(x)
+	case float64:
+		s = providers.FormatNumber(x)
+	case string:
+		s = x
+	default:
+		s = fmt.Sprint(x)
+	}
+
+	whole, frac, hasFrac := strings.Cut(s, ".")
+	if whole == "" || strings.TrimFunc(whole, unicode.IsDigit) != "" {
+		return s
+	}
+	if len(whole) < width {
+		whole = strings.Repeat("0", width-len(whole)) + whole
+	}
+	if hasFrac {
+		return whole + "." + frac
+	}
+
+	return whole
+}
+
+// titleName is the title without the chapter numbering sites repeat in it.
+func titleName(title string) string {
+	return strings.TrimSpace(reTitleNumber.ReplaceAllString(strings.TrimSpace(title), ""))
+}
+
+// titleCase upper-cases the first letter of every word.
+func titleCase(s string) string {
+	runes := []rune(s)
+	for i, r := range runes {
+		if i == 0 || unicode.IsSpace(runes[i-1]) || runes[i-1] == '-' || runes[i-1] == '(' {
+			runes[i] = unicode.ToUpper(r)
+		}
+	}
+
+	return string(runes)
+}
+
+// withSuffix inserts suffix before the .cbz extension.
+func (n *Namer) withSuffix(p, suffix string) string {
+	dir, file := filepath.Split(p)
+	ext := filepath.Ext(file)
+
+	return filepath.Join(dir, n.policy.CleanFile(strings.TrimSuffix(file, ext)+suffix, ext))
+}
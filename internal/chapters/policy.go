@@ -0,0 +1,165 @@
+package chapters
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Policy decides which characters may appear in file and folder names. Only
+// characters that the target filesystem can't store are replaced; case,
+// punctuation and Unicode titles are kept.
+type Policy string
+
+const (
+	PolicyPOSIX   Policy = "posix"   // Linux/macOS: only "/" and NUL are illegal
+	PolicyWindows Policy = "windows" // NTFS, exFAT, SMB shares
+	PolicyFAT32   Policy = "fat32"   // SD cards and e-readers: Windows rules, BMP characters only
+	PolicyASCII   Policy = "ascii"   // Windows rules, ASCII only
+	PolicySlug    Policy = "slug"    // the old lowercase_underscore default names; Windows rules for templates
+)
+
+// maxNameLen is the longest file or folder name: 255 bytes on POSIX
+// filesystems, 255 UTF-16 units on Windows and FAT32.
+const maxNameLen = 255
+
+// windowsReserved are device names Windows won't create files for, with or
+// without an extension.
+var windowsReserved = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true, "COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true, "LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// asciiFold maps common non-ASCII letters and punctuation to ASCII for
+// PolicyASCII.
+var asciiFold = strings.NewReplacer(
+	"à", "a", "á", "a", "â", "a", "ã", "a", "ä", "a", "å", "a", "æ", "ae", "ç", "c",
+	"è", "e", "é", "e", "ê", "e", "ë", "e", "ì", "i", "í", "i", "î", "i", "ï", "i",
+	"ñ", "n", "ò", "o", "ó", "o", "ô", "o", "õ", "o", "ö", "o", "ø", "o", "œ", "oe",
+	"ù", "u", "ú", "u", "û", "u", "ü", "u", "ý", "y", "ÿ", "y", "ß", "ss",
+	"À", "A", "Á", "A", "Â", "A", "Ã", "A", "Ä", "A", "Å", "A", "Æ", "AE", "Ç", "C",
+	"È", "E", "É", "E", "Ê", "E", "Ë", "E", "Ì", "I", "Í", "I", "Î", "I", "Ï", "I",
+	"Ñ", "N", "Ò", "O", "Ó", "O", "Ô", "O", "Õ", "O", "Ö", "O", "Ø", "O", "Œ", "OE",
+	"Ù", "U", "Ú", "U", "Û", "U", "Ü", "U", "Ý", "Y",
+	"ā", "a", "ē", "e", "ī", "i", "ō", "o", "ū", "u", "Ā", "A", "Ē", "E", "Ī", "I", "Ō", "O", "Ū", "U",
+	"‘", "'", "’", "'", "“", "'", "”", "'", "«", "'", "»", "'",
+	"–", "-", "—", "-", "‐", "-", "…", "...", "•", "-", "·", "-", "×", "x",
+	"\u00a0", " ", "\u3000", " ",
+)
+
+// ParsePolicy accepts posix, windows (or ntfs), fat32, ascii and slug. An empty
+// name gives PolicyWindows, which is safe everywhere but keeps titles
+// readable.
+func ParsePolicy(s string) (Policy, error) {
+	switch p := Policy(strings.ToLower(strings.TrimSpace(s))); p {
+	case "", "ntfs":
+		return PolicyWindows, nil
+	case PolicyPOSIX, PolicyWindows, PolicyFAT32, PolicyASCII, PolicySlug:
+		return p, nil
+	}
+
+	return "", fmt.Errorf("unknown filename policy %q (use posix, windows, fat32, ascii or slug)", s)
+}
+
+// Clean makes s usable as one file or folder name.
+func (p Policy) Clean(s string) string {
+	return p.CleanFile(s, "")
+}
+
+// CleanFile makes base+ext usable as a file name, shortening base when the
+// name would be too long so that ext survives.
+func (p Policy) CleanFile(base, ext string) string {
+	if p == PolicyASCII {
+		base = asciiFold.Replace(base)
+	}
+
+	var b strings.Builder
+	for _, r := range base {
+		if p.illegal(r) {
+			if !strings.HasSuffix(b.String(), "_") {
+				b.WriteByte('_')
+			}
+			continue
+		}
+		b.WriteRune(r)
+	}
+
+	name := p.trim(b.String())
+	if name == "" {
+		return ""
+	}
+	if p != PolicyPOSIX {
+		stem, _, _ := strings.Cut(name, ".")
+		if windowsReserved[strings.ToUpper(strings.TrimSpace(stem))] {
+			name = stem + "_" + strings.TrimPrefix(name, stem)
+		}
+	}
+
+	for p.length(name+ext) > maxNameLen && name != "" {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = p.trim(name[:len(name)-size])
+	}
+
+	return name + ext
+}
+
+func (p Policy) illegal(r rune) bool {
+	if r < 0x20 || r == 0x7f || r == '/' || r == utf8.RuneError {
+		return true
+	}
+
+	switch p {
+	case PolicyPOSIX:
+		return false
+	case PolicyFAT32:
+		return strings.ContainsRune(`<>:"\|?*`, r) || r > 0xffff
+	case PolicyASCII:
+		return strings.ContainsRune(`<>:"\|?*`, r) || r > 0x7e
+	default:
+		return strings.ContainsRune(`<>:"\|?*`, r)
+	}
+}
+
+// trim drops surrounding spaces and leading dots (hidden files, "..") on
+// every filesystem, and trailing dots, which Windows silently removes.
+func (p Policy) trim(s string) string {
+	s = strings.TrimLeft(strings.TrimSpace(s), ".")
+	if p != PolicyPOSIX {
+		s = strings.TrimRight(s, ". ")
+	}
+
+	return strings.TrimSpace(s)
+}
+
+func (p Policy) length(s string) int {
+	if p == PolicyPOSIX {
+		return len(s)
+	}
+
+	return len(utf16.Encode([]rune(s)))
+}
+
+// cleanPath cleans every folder of a relative path and the file name, and
+// makes sure the file ends in ext.
+func (p Policy) cleanPath(raw, ext string) string {
+	segs := strings.Split(raw, "/")
+	file := segs[len(segs)-1]
+	if strings.EqualFold(filepath.Ext(file), ext) {
+		file = file[:len(file)-len(ext)]
+	}
+
+	var out []string
+	for _, seg := range segs[:len(segs)-1] {
+		if seg = p.Clean(seg); seg != "" {
+			out = append(out, seg)
+		}
+	}
+	if file = p.CleanFile(file, ext); file == ext || file == "" {
+		return ""
+	}
+
+	return filepath.Join(append(out, file)...)
+}
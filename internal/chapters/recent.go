@@ -0,0 +1,116 @@
+package chapters
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/brogergvhs/mangad/internal/providers"
+)
+
+// ParseSince accepts a date ("2024-01-15") or an age relative to now
+// ("36h", "14d", "2w", "3m", "1y").
+func ParseSince(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+
+	s = strings.ToLower(s)
+
+	if len(s) >= 2 {
+		n, err := strconv.Atoi(s[:len(s)-1])
+		if err == nil && n >= 0 {
+			switch s[len(s)-1] {
+			case 'h':
+				return now.Add(-time.Duration(n) * time.Hour), nil
+			case 'd':
+				return now.AddDate(0, 0, -n), nil
+			case 'w':
+				return now.AddDate(0, 0, -7*n), nil
+			case 'm':
+				return now.AddDate(0, -n, 0), nil
+			case 'y':
+				return now.AddDate(-n, 0, 0), nil
+			}
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid date %q (use YYYY-MM-DD or an age like 14d, 2w, 3m)", s)
+}
+
+// Dated reports how many chapters carry a release date.
+func Dated(all []Chapter) int {
+	n := 0
+	for _, ch := range all {
+		if !ch.Date.IsZero() {
+			n++
+		}
+	}
+
+	return n
+}
+
+// Since keeps chapters released at or after t. Chapters without a date
+// are dropped.
+func Since(all []Chapter, t time.Time) []Chapter {
+	out := make([]Chapter, 0, len(all))
+	for _, ch := range all {
+		if !ch.Date.IsZero() && !ch.Date.Before(t) {
+			out = append(out, ch)
+		}
+	}
+
+	return out
+}
+
+// Latest keeps the n most recent chapters in list order: by release date
+// when the list has dates, otherwise the n highest-numbered regular chapters.
+func Latest(all []Chapter, n int) []Chapter {
+	if Dated(all) == 0 {
+		return Selection{include: []term{latest(n)}}.Apply(all)
+	}
+
+	idx := make([]int, 0, len(all))
+	for i, ch := range all {
+		if !ch.Date.IsZero() {
+			idx = append(idx, i)
+		}
+	}
+	sort.SliceStable(idx, func(a, b int) bool { return all[idx[a]].Date.After(all[idx[b]].Date) })
+
+	keep := make([]bool, len(all))
+	for _, i := range idx[:min(n, len(idx))] {
+		keep[i] = true
+	}
+
+	return buildResult(all, keep)
+}
+
+// AfterNewest keeps the chapters listed after the last regular chapter for
+// which have returns true (the newest chapter already downloaded), skipping
+// any that are already present. With nothing downloaded yet every chapter
+// is kept.
+func AfterNewest(all []Chapter, have func(Chapter) bool) []Chapter {
+	newest := -1
+	for i, ch := range all {
+		if chapterKind(ch) == providers.KindRegular && have(ch) {
+			newest = i
+		}
+	}
+
+	out := make([]Chapter, 0, len(all))
+	for _, ch := range all[newest+1:] {
+		if !have(ch) {
+			out = append(out, ch)
+		}
+	}
+
+	return out
+}
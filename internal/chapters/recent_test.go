@@ -0,0 +1,41 @@
+package chapters
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2025, time.June, 15, 12, 30, 0, 0, time.UTC)
+
+	for _, tc := range []struct {
+		in   string
+		want time.Time
+	}{
+		{"2024-01-15", time.Date(2024, time.January, 15, 0, 0, 0, 0, time.UTC)},
+		{" 2024-01-15 ", time.Date(2024, time.January, 15, 0, 0, 0, 0, time.UTC)},
+		{"2024-01-15T10:00:00+02:00", time.Date(2024, time.January, 15, 8, 0, 0, 0, time.UTC)},
+		{"36h", now.Add(-36 * time.Hour)},
+		{"14d", time.Date(2025, time.June, 1, 12, 30, 0, 0, time.UTC)},
+		{"14D", time.Date(2025, time.June, 1, 12, 30, 0, 0, time.UTC)},
+		{"2w", time.Date(2025, time.June, 1, 12, 30, 0, 0, time.UTC)},
+		{"3m", time.Date(2025, time.March, 15, 12, 30, 0, 0, time.UTC)},
+		{"1y", time.Date(2024, time.June, 15, 12, 30, 0, 0, time.UTC)},
+		{"0d", now},
+	} {
+		got, err := ParseSince(tc.in, now)
+		if err != nil {
+			t.Errorf("ParseSince(%q): %v", tc.in, err)
+			continue
+		}
+		if !got.Equal(tc.want) {
+			t.Errorf("ParseSince(%q) = %v, want %v", tc.in, got, tc.want)
+		}
+	}
+
+	for _, in := range []string{"", "d", "-3d", "3x", "3 days", "yesterday", "2024-13-01", "15/01/2024"} {
+		if got, err := ParseSince(in, now); err == nil {
+			t.Errorf("ParseSince(%q) = %v, want an error", in, got)
+		}
+	}
+}
@@ -107,6 +107,9 @@ func buildResult(all []Chapter, keep []bool) []Chapter {
 	return out
 }
 
+// FilterChaptersByLabel returns chapters whose label equals label. When
+// nothing matches exactly it falls back to number/volume/kind matching
+// (e.g. "12.50", "vol3/27", "extra-2").
 func FilterChaptersByLabel(all []Chapter, label string) []Chapter {
 	out := make([]Chapter, 0, 4)
 	for _, ch := range all {
@@ -114,6 +117,15 @@ func FilterChaptersByLabel(all []Chapter, label string) []Chapter {
 			out = append(out, ch)
 		}
 	}
+	if len(out) > 0 {
+		return out
+	}
+
+	for _, ch := range all {
+		if ch.MatchesLabel(label) {
+			out = append(out, ch)
+		}
+	}
 
 	return out
 }
@@ -17,6 +17,7 @@ type Config struct {
 	AllowExt       []string `yaml:"allow_ext"`
 
 	DefaultURL          string `yaml:"default_url"`
+	Chapters            string `yaml:"chapters"` // label-based selection expression, preferred over the index ranges
 	DefaultRange        string `yaml:"default_range"`
 	DefaultExcludeRange string `yaml:"default_exclude_range"`
 	DefaultList         string `yaml:"default_list"`
@@ -29,6 +30,29 @@ type Config struct {
 	UserAgent  string `yaml:"user_agent"`
 
 	SkipBroken bool `yaml:"skip_broken"`
+
+	NameTemplate   string `yaml:"name_template"`   // CBZ path template, see chapters.Namer
+	FilenamePolicy string `yaml:"filename_policy"` // posix, windows (default), fat32, ascii or slug
+	Layout         string `yaml:"layout"`          // flat (default) or library: <output>/<Series>/ with series.json and cover.jpg
+	SeriesName     string `yaml:"series_name"`     // overrides the scraped series name
+
+	ConvertWebP   string `yaml:"convert_webp"`   // convert WebP pages to jpeg or png
+	ConvertPNG    string `yaml:"convert_png"`    // convert PNG pages to jpeg
+	JPEGQuality   int    `yaml:"jpeg_quality"`   // quality of converted JPEGs, 1-100 (default 85)
+	StripMetadata bool   `yaml:"strip_metadata"` // drop EXIF, XMP and text metadata from pages
+
+	PreferredGroups    []string `yaml:"preferred_groups"`
+	PreferredLanguages []string `yaml:"preferred_languages"`
+
+	BlockPatterns []string `yaml:"block_patterns"`
+	ProbeImages   bool     `yaml:"probe_images"`
+
+	FetcherCommand      []string `yaml:"fetcher_command"`
+	FetcherProxy        string   `yaml:"fetcher_proxy"`
+	FetcherWaitSelector string   `yaml:"fetcher_wait_selector"`
+	FetcherWaitSeconds  float64  `yaml:"fetcher_wait_seconds"`
+
+	SearchSites []SearchSite `yaml:"search_sites,omitempty"`
 }
 
 type Options struct {
@@ -39,6 +63,7 @@ type Options struct {
 	ChapterWorkers      int
 	KeepFolders         bool
 	DefaultURL          string
+	Chapters            string
 	DefaultRange        string
 	DefaultExcludeRange string
 	DefaultList         string
@@ -49,6 +74,15 @@ type Options struct {
 	CookieFile          string
 	UserAgent           string
 	SkipBroken          bool
+	ProbeImages         bool
+	NameTemplate        string
+	FilenamePolicy      string
+	Layout              string
+	SeriesName          string
+	ConvertWebP         string
+	ConvertPNG          string
+	JPEGQuality         int
+	StripMetadata       bool
 }
 
 func DefaultConfig() *Config {
@@ -69,10 +103,18 @@ func DefaultConfig() *Config {
 		CheckJS:             false,
 		WithCF:              false,
 		SkipBroken:          false,
-		AllowExt:            []string{"jpg", "jpeg", "png", "webp"},
+		AllowExt:            []string{"jpg", "jpeg", "png", "webp", "gif", "avif", "jxl"},
 	}
 }
 
+// ClearSelection drops the chapter selection, which only makes sense for
+// the profile's own series.
+func (c *Config) ClearSelection() {
+	c.Chapters = ""
+	c.DefaultRange, c.DefaultExcludeRange = "", ""
+	c.DefaultList, c.DefaultExcludeList = "", ""
+}
+
 func SaveYAML(cfg *Config, path string) error {
 	data, err := yaml.Marshal(cfg)
 	if err != nil {
@@ -145,6 +187,9 @@ func mergeConfig(c *Config, o Options) {
 	if o.DefaultURL != "" {
 		c.DefaultURL = o.DefaultURL
 	}
+	if o.Chapters != "" {
+		c.Chapters = o.Chapters
+	}
 	if o.DefaultRange != "" {
 		c.DefaultRange = o.DefaultRange
 	}
@@ -175,6 +220,33 @@ func mergeConfig(c *Config, o Options) {
 	if o.SkipBroken {
 		c.SkipBroken = true
 	}
+	if o.ProbeImages {
+		c.ProbeImages = true
+	}
+	if o.NameTemplate != "" {
+		c.NameTemplate = o.NameTemplate
+	}
+	if o.FilenamePolicy != "" {
+		c.FilenamePolicy = o.FilenamePolicy
+	}
+	if o.Layout != "" {
+		c.Layout = o.Layout
+	}
+	if o.SeriesName != "" {
+		c.SeriesName = o.SeriesName
+	}
+	if o.ConvertWebP != "" {
+		c.ConvertWebP = o.ConvertWebP
+	}
+	if o.ConvertPNG != "" {
+		c.ConvertPNG = o.ConvertPNG
+	}
+	if o.JPEGQuality != 0 {
+		c.JPEGQuality = o.JPEGQuality
+	}
+	if o.StripMetadata {
+		c.StripMetadata = true
+	}
 }
 
 func normalizeDefaults(c *Config) {
@@ -204,6 +276,9 @@ func (c *Config) Print() {
 	if c.DefaultURL != "" {
 		fmt.Printf(" -url: %s\n", c.DefaultURL)
 	}
+	if c.Chapters != "" {
+		fmt.Printf(" -chapters: %s\n", c.Chapters)
+	}
 	if c.DefaultRange != "" {
 		fmt.Printf(" -range: %s\n", c.DefaultRange)
 	}
@@ -231,7 +306,62 @@ func (c *Config) Print() {
 	if c.SkipBroken {
 		fmt.Printf(" -skip_broken: %t\n", c.SkipBroken)
 	}
+	if c.NameTemplate != "" {
+		fmt.Printf(" -name_template: %s\n", c.NameTemplate)
+	}
+	if c.FilenamePolicy != "" {
+		fmt.Printf(" -filename_policy: %s\n", c.FilenamePolicy)
+	}
+	if c.Layout != "" {
+		fmt.Printf(" -layout: %s\n", c.Layout)
+	}
+	if c.SeriesName != "" {
+		fmt.Printf(" -series_name: %s\n", c.SeriesName)
+	}
+	if c.ConvertWebP != "" {
+		fmt.Printf(" -convert_webp: %s\n", c.ConvertWebP)
+	}
+	if c.ConvertPNG != "" {
+		fmt.Printf(" -convert_png: %s\n", c.ConvertPNG)
+	}
+	if c.JPEGQuality != 0 {
+		fmt.Printf(" -jpeg_quality: %d\n", c.JPEGQuality)
+	}
+	if c.StripMetadata {
+		fmt.Printf(" -strip_metadata: %t\n", c.StripMetadata)
+	}
 	if len(c.AllowExt) > 0 {
 		fmt.Printf(" -allow_ext: %s\n", strings.Join(c.AllowExt, ", "))
 	}
+	if len(c.PreferredGroups) > 0 {
+		fmt.Printf(" -preferred_groups: %s\n", strings.Join(c.PreferredGroups, ", "))
+	}
+	if len(c.PreferredLanguages) > 0 {
+		fmt.Printf(" -preferred_languages: %s\n", strings.Join(c.PreferredLanguages, ", "))
+	}
+	if len(c.BlockPatterns) > 0 {
+		fmt.Printf(" -block_patterns: %s\n", strings.Join(c.BlockPatterns, ", "))
+	}
+	if c.ProbeImages {
+		fmt.Printf(" -probe_images: %t\n", c.ProbeImages)
+	}
+	if len(c.FetcherCommand) > 0 {
+		fmt.Printf(" -fetcher_command: %s\n", strings.Join(c.FetcherCommand, " "))
+	}
+	if c.FetcherProxy != "" {
+		fmt.Printf(" -fetcher_proxy: %s\n", c.FetcherProxy)
+	}
+	if c.FetcherWaitSelector != "" {
+		fmt.Printf(" -fetcher_wait_selector: %s\n", c.FetcherWaitSelector)
+	}
+	if c.FetcherWaitSeconds > 0 {
+		fmt.Printf(" -fetcher_wait_seconds: %g\n", c.FetcherWaitSeconds)
+	}
+	if len(c.SearchSites) > 0 {
+		names := make([]string, 0, len(c.SearchSites))
+		for _, site := range c.SearchSites {
+			names = append(names, site.Name)
+		}
+		fmt.Printf(" -search_sites: %s\n", strings.Join(names, ", "))
+	}
 }
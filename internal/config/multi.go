@@ -195,3 +195,21 @@ func ConfigPathByLabel(label string) (string, error) {
 
 	return path, nil
 }
+
+// UpdateActive loads the active config file as stored (without CLI
+// overrides), applies fn and writes it back. It returns the file path.
+func UpdateActive(fn func(c *Config)) (string, error) {
+	path, err := ActiveConfigPath()
+	if err != nil {
+		return "", err
+	}
+
+	cfg, err := loadYAML(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to load config %s: %w", path, err)
+	}
+
+	fn(cfg)
+
+	return path, SaveYAML(cfg, path)
+}
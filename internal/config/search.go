@@ -0,0 +1,71 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SearchSite describes how to query one site's search. Results are read
+// from HTML with CSS selectors (Results set) or from JSON with dot paths
+// (JSONPath set).
+//
+// For HTML, Title/URL/Cover are "selector" for the element text or
+// "selector@attr" for an attribute, relative to each result; "@attr" reads
+// the result element itself. For JSON they are dot paths into each result
+// (e.g. "attributes.title.en"); a URL containing {path} placeholders is
+// treated as a template, e.g. "https://example.com/title/{id}".
+type SearchSite struct {
+	Name      string `yaml:"name"`
+	SearchURL string `yaml:"search_url"` // {query} is replaced with the escaped query
+	Results   string `yaml:"results,omitempty"`
+	JSONPath  string `yaml:"json_path,omitempty"`
+	Title     string `yaml:"title,omitempty"`
+	URL       string `yaml:"url,omitempty"`
+	Cover     string `yaml:"cover,omitempty"`
+}
+
+// SearchSitesFile holds site definitions shared by all profiles.
+func SearchSitesFile() string {
+	return filepath.Join(ConfigRoot(), "search_sites.yaml")
+}
+
+// LoadSearchSites returns the shared site definitions followed by the
+// profile's own. A profile entry replaces a shared one with the same name.
+func LoadSearchSites(cfg *Config) ([]SearchSite, error) {
+	var shared []SearchSite
+
+	b, err := os.ReadFile(SearchSitesFile())
+	switch {
+	case err == nil:
+		if err := yaml.Unmarshal(b, &shared); err != nil {
+			return nil, fmt.Errorf("failed to load %s: %w", SearchSitesFile(), err)
+		}
+	case !os.IsNotExist(err):
+		return nil, err
+	}
+
+	out := make([]SearchSite, 0, len(shared)+len(cfg.SearchSites))
+	index := map[string]int{}
+	for _, site := range append(shared, cfg.SearchSites...) {
+		if i, ok := index[site.Name]; ok {
+			out[i] = site
+			continue
+		}
+		index[site.Name] = len(out)
+		out = append(out, site)
+	}
+
+	for _, site := range out {
+		if site.Name == "" || site.SearchURL == "" {
+			return nil, fmt.Errorf("search site %q needs a name and a search_url", site.Name)
+		}
+		if site.Results == "" && site.JSONPath == "" {
+			return nil, fmt.Errorf("search site %q needs either results (HTML) or json_path (JSON)", site.Name)
+		}
+	}
+
+	return out, nil
+}
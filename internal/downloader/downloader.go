@@ -1,35 +1,73 @@
 package downloader
 
 import (
+	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"mime"
 	"net/http"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/brogergvhs/mangad/internal/imgfmt"
 	"github.com/brogergvhs/mangad/internal/ui"
+	"github.com/brogergvhs/mangad/internal/util"
 )
 
+// sniffLen is how much of a response is read ahead to identify it.
+const sniffLen = 512
+
+// ErrFormat is returned for pages whose format is not in allow_ext. They are
+// not retried.
+var ErrFormat = errors.New("image format not allowed")
+
 type Downloader struct {
 	client     *http.Client
 	debug      bool
 	outputDir  string
 	skipBroken bool
+	formats    imgfmt.Set
 }
 
-func New(c *http.Client, debug bool, outputDir string, skipBroken bool) *Downloader {
+func New(c *http.Client, debug bool, outputDir string, skipBroken bool, formats imgfmt.Set) *Downloader {
 	return &Downloader{
 		client:     c,
 		debug:      debug,
 		outputDir:  outputDir,
 		skipBroken: skipBroken,
+		formats:    formats,
 	}
 }
 
+// PartialError reports the pages of a chapter that could not be downloaded.
+// With skip-broken the other pages are still returned and Skipped is set.
+type PartialError struct {
+	Pages   []int // 1-based page numbers
+	Total   int
+	Skipped bool
+	Err     error // the last page error
+}
+
+func (e *PartialError) Error() string {
+	if e.Skipped {
+		return fmt.Sprintf("skipped %d/%d images: %v", len(e.Pages), e.Total, e.Err)
+	}
+
+	return fmt.Sprintf("failed %d/%d images (use --skip-broken to continue): %v", len(e.Pages), e.Total, e.Err)
+}
+
+func (e *PartialError) Unwrap() error {
+	return e.Err
+}
+
 type chapterState struct {
 	mu          sync.Mutex
 	doneImages  int
@@ -37,6 +75,8 @@ type chapterState struct {
 	doneBytes   int64
 }
 
+// DownloadImagesConcurrently saves urls into folder as page_001.jpg, ... and
+// returns the saved files in the order of urls, which is the reading order.
 func (d *Downloader) DownloadImagesConcurrently(
 	ctx context.Context,
 	urls []string,
@@ -61,9 +101,11 @@ func (d *Downloader) DownloadImagesConcurrently(
 	cs := &chapterState{totalImages: total}
 	ph.Update(0, total, 0)
 
-	var filesMu sync.Mutex
-	files := make([]string, 0, len(urls))
-	errs := make([]error, 0, 4)
+	// files is indexed by page so the archive keeps the order of urls
+	files := make([]string, total)
+	width := pageWidth(total)
+	var failed []int
+	var lastErr error
 
 	jobs := make(chan int)
 	var wg sync.WaitGroup
@@ -72,22 +114,7 @@ func (d *Downloader) DownloadImagesConcurrently(
 		defer wg.Done()
 		for i := range jobs {
 			u := urls[i]
-			low := strings.ToLower(u)
-
-			if strings.HasSuffix(low, ".gif") {
-				cs.mu.Lock()
-				cs.doneImages++
-				ph.Update(cs.doneImages, cs.totalImages, cs.doneBytes)
-				cs.mu.Unlock()
-				continue
-			}
-
-			ext := filepath.Ext(u)
-			if ext == "" {
-				ext = ".jpg"
-			}
-
-			path := filepath.Join(folder, fmt.Sprintf("page_%03d%s", i+1, ext))
+			base := filepath.Join(folder, fmt.Sprintf("page_%0*d", width, i+1))
 			var last int64
 
 			progress := func(done int64) {
@@ -103,9 +130,11 @@ func (d *Downloader) DownloadImagesConcurrently(
 				cs.mu.Unlock()
 			}
 
-			if err := d.downloadWithRetry(ctx, u, path, referer, progress); err != nil {
+			file, err := d.downloadWithRetry(ctx, u, base, referer, progress)
+			if err != nil {
 				cs.mu.Lock()
-				errs = append(errs, fmt.Errorf("image %d: %v", i+1, err))
+				failed = append(failed, i+1)
+				lastErr = err
 				cs.doneImages++
 				ph.Update(cs.doneImages, cs.totalImages, cs.doneBytes)
 				cs.mu.Unlock()
@@ -113,11 +142,8 @@ func (d *Downloader) DownloadImagesConcurrently(
 				continue
 			}
 
-			filesMu.Lock()
-			files = append(files, path)
-			filesMu.Unlock()
-
 			cs.mu.Lock()
+			files[i] = file
 			cs.doneImages++
 			ph.Update(cs.doneImages, cs.totalImages, cs.doneBytes)
 			cs.mu.Unlock()
@@ -135,7 +161,7 @@ func (d *Downloader) DownloadImagesConcurrently(
 			close(jobs)
 			wg.Wait()
 			ph.MarkDone()
-			return files, cs.doneBytes, ctx.Err()
+			return compact(files), cs.doneBytes, ctx.Err()
 		case jobs <- i:
 		}
 	}
@@ -144,48 +170,70 @@ func (d *Downloader) DownloadImagesConcurrently(
 	wg.Wait()
 	ph.MarkDone()
 
-	if len(errs) > 0 && !d.skipBroken {
-		return files, cs.doneBytes, fmt.Errorf("failed %d/%d images (use --skip-broken to continue)", len(errs), total)
+	if len(failed) > 0 {
+		sort.Ints(failed)
+		return compact(files), cs.doneBytes, &PartialError{Pages: failed, Total: total, Skipped: d.skipBroken, Err: lastErr}
 	}
 
 	return files, cs.doneBytes, nil
 }
 
+// pageWidth is the number of digits page numbers are padded to, so that
+// names sort in reading order: at least three, more for long webtoons.
+func pageWidth(total int) int {
+	return max(3, len(strconv.Itoa(total)))
+}
+
+// compact drops the pages that were not downloaded, keeping the order.
+func compact(files []string) []string {
+	out := make([]string, 0, len(files))
+	for _, f := range files {
+		if f != "" {
+			out = append(out, f)
+		}
+	}
+
+	return out
+}
+
+// downloadWithRetry saves the image at url as base plus the extension of
+// its real format and returns the path.
 func (d *Downloader) downloadWithRetry(
 	ctx context.Context,
 	url string,
-	output string,
+	base string,
 	referer string,
 	progress func(done int64),
-) error {
+) (string, error) {
 	var err error
+	var output string
 	for attempt := 1; attempt <= 3; attempt++ {
-		err = d.download(ctx, url, output, referer, progress)
-		if err == nil {
-			return nil
+		output, err = d.download(ctx, url, base, referer, progress)
+		if err == nil || errors.Is(err, ErrFormat) {
+			return output, err
 		}
 
 		select {
 		case <-ctx.Done():
-			return ctx.Err()
+			return "", ctx.Err()
 		case <-time.After(time.Duration(attempt) * time.Second):
 		}
 	}
 
-	return err
+	return "", err
 }
 
 func (d *Downloader) download(
 	ctx context.Context,
-	u, output, referer string,
+	u, base, referer string,
 	progress func(done int64),
-) error {
+) (string, error) {
 	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
 	req, err := http.NewRequestWithContext(ctx, "GET", u, nil)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	req.Header.Set("Referer", referer)
@@ -197,7 +245,7 @@ func (d *Downloader) download(
 
 	resp, err := d.client.Do(req)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	var bodyCloseErr error
@@ -208,18 +256,20 @@ func (d *Downloader) download(
 	}()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("HTTP %d", resp.StatusCode)
+		return "", &util.HTTPError{Status: resp.StatusCode, URL: u}
 	}
 
-	if ct := resp.Header.Get("Content-Type"); ct != "" {
-		if mt, _, _ := mime.ParseMediaType(ct); !strings.HasPrefix(mt, "image/") {
-			return fmt.Errorf("unexpected MIME: %s", ct)
-		}
+	body := bufio.NewReaderSize(resp.Body, sniffLen)
+	head, _ := body.Peek(sniffLen)
+	ext, err := d.pageExt(u, head, resp.Header.Get("Content-Type"))
+	if err != nil {
+		return "", err
 	}
 
+	output := base + ext
 	f, err := os.Create(output)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	var fileCloseErr error
@@ -229,9 +279,9 @@ func (d *Downloader) download(
 		}
 	}()
 
-	written, err := copyWithProgress(f, resp.Body, progress)
+	written, err := copyWithProgress(f, body, progress)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	if progress != nil && resp.ContentLength > 0 && written < resp.ContentLength {
@@ -239,8 +289,62 @@ func (d *Downloader) download(
 	}
 
 	if fileCloseErr != nil {
-		return fileCloseErr
+		return "", fileCloseErr
+	}
+
+	return output, bodyCloseErr
+}
+
+// pageExt works out the real format of a page from its first bytes, falling
+// back to the Content-Type, checks it against allow_ext and returns the
+// extension to save it with. HTML or text served in place of an image (error
+// and login pages, hotlink notices) is refused. Formats mangad doesn't know
+// are only accepted when the URL ends in an extension allow_ext lists.
+func (d *Downloader) pageExt(u string, head []byte, contentType string) (string, error) {
+	if len(head) == 0 {
+		return "", errors.New("empty response")
+	}
+
+	f, ok := imgfmt.Sniff(head)
+	if !ok {
+		if sniffed := http.DetectContentType(head); strings.HasPrefix(sniffed, "text/") {
+			return "", fmt.Errorf("got %s instead of an image", strings.TrimSuffix(sniffed, "; charset=utf-8"))
+		}
+		f, ok = imgfmt.ByMIME(contentType)
+	}
+	if ok {
+		if !d.formats.Allows(f) {
+			return "", fmt.Errorf("%w: %s (allow_ext: %s)", ErrFormat, f.Name, strings.Join(d.formats.Names(), ", "))
+		}
+		return f.Ext(), nil
+	}
+
+	if contentType != "" {
+		if mt, _, _ := mime.ParseMediaType(contentType); !strings.HasPrefix(mt, "image/") {
+			return "", fmt.Errorf("unexpected MIME: %s", contentType)
+		}
+	}
+	if ext := urlExt(u); ext != "" && d.formats.AllowsExt(ext) {
+		return "." + ext, nil
+	}
+
+	return "", fmt.Errorf("%w: unrecognized image (%s)", ErrFormat, firstNonEmpty(contentType, "no Content-Type"))
+}
+
+// urlExt is the lower-case extension of the URL path, without the query.
+func urlExt(u string) string {
+	pu, err := url.Parse(u)
+	if err != nil {
+		return ""
+	}
+
+	return strings.ToLower(strings.TrimPrefix(path.Ext(pu.Path), "."))
+}
+
+func firstNonEmpty(a, b string) string {
+	if a != "" {
+		return a
 	}
 
-	return bodyCloseErr
+	return b
 }
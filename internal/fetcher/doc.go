@@ -0,0 +1,4 @@
+// Package fetcher runs external page fetchers (headless browsers and
+// similar tools) that speak a small JSON protocol over stdin/stdout.
+// The bundled SeleniumBase script is the default implementation.
+package fetcher
@@ -0,0 +1,229 @@
+package fetcher
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+//go:embed selenium_fetch.py
+var embeddedSeleniumScript []byte
+
+// Request is written as JSON to the fetcher's stdin.
+type Request struct {
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Cookies []Cookie          `json:"cookies,omitempty"`
+	Proxy   string            `json:"proxy,omitempty"`
+	Wait    Wait              `json:"wait,omitzero"`
+}
+
+// Wait tells the fetcher when the page is ready.
+type Wait struct {
+	Selector string  `json:"selector,omitempty"` // CSS selector that must be present
+	Seconds  float64 `json:"seconds,omitempty"`  // extra settle time after load
+	Timeout  float64 `json:"timeout,omitempty"`  // max seconds to wait for Selector
+}
+
+// Response is read as JSON from the fetcher's stdout.
+type Response struct {
+	HTML      string   `json:"html"`
+	URL       string   `json:"url,omitempty"` // final URL after redirects
+	Cookies   []Cookie `json:"cookies,omitempty"`
+	UserAgent string   `json:"user_agent,omitempty"`
+	Error     string   `json:"error,omitempty"`
+}
+
+type Cookie struct {
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	Domain   string `json:"domain,omitempty"`
+	Path     string `json:"path,omitempty"`
+	Expires  int64  `json:"expires,omitempty"` // unix seconds, 0 for session cookies
+	Secure   bool   `json:"secure,omitempty"`
+	HTTPOnly bool   `json:"http_only,omitempty"`
+}
+
+type Fetcher interface {
+	Fetch(ctx context.Context, req Request) (*Response, error)
+}
+
+// External runs a command per request. With an empty Command it runs the
+// bundled SeleniumBase script through python3.
+type External struct {
+	Command []string
+}
+
+func New(command []string) *External {
+	return &External{Command: command}
+}
+
+// SplitCommand splits a command line into its arguments. Single and double
+// quotes group words ("C:\Program Files\fetch.exe"), and outside single
+// quotes a backslash escapes a following quote, space or backslash.
+func SplitCommand(s string) ([]string, error) {
+	var (
+		args  []string
+		cur   strings.Builder
+		inArg bool
+		quote rune
+		esc   bool
+	)
+	for _, r := range s {
+		switch {
+		case esc:
+			if !strings.ContainsRune(`"'\ `, r) {
+				cur.WriteRune('\\') // keep Windows paths like C:\tools\fetch.exe
+			}
+			cur.WriteRune(r)
+			esc = false
+		case r == '\\' && quote != '\'':
+			esc, inArg = true, true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote, inArg = r, true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, cur.String())
+				cur.Reset()
+				inArg = false
+			}
+		default:
+			cur.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote in %q", quote, s)
+	}
+	if esc {
+		cur.WriteRune('\\')
+	}
+	if inArg {
+		args = append(args, cur.String())
+	}
+
+	return args, nil
+}
+
+func (e *External) Name() string {
+	if len(e.Command) == 0 {
+		return "embedded Selenium"
+	}
+
+	return strings.Join(e.Command, " ")
+}
+
+func (e *External) Fetch(ctx context.Context, req Request) (*Response, error) {
+	args := e.Command
+	if len(args) == 0 {
+		script, cleanup, err := writeEmbeddedScript()
+		if err != nil {
+			return nil, err
+		}
+		defer cleanup()
+
+		args = []string{"python3", script}
+	}
+
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+
+	var out bytes.Buffer
+	var stderr bytes.Buffer
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+
+	runErr := cmd.Run()
+
+	var resp Response
+	if err := json.Unmarshal(bytes.TrimSpace(out.Bytes()), &resp); err != nil {
+		if runErr != nil {
+			return nil, fmt.Errorf("fetcher %s failed: %w\nstderr: %s", e.Name(), runErr, stderr.String())
+		}
+		return nil, fmt.Errorf("fetcher %s returned invalid JSON: %w", e.Name(), err)
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("fetcher %s: %s", e.Name(), resp.Error)
+	}
+	if runErr != nil {
+		return nil, fmt.Errorf("fetcher %s failed: %w\nstderr: %s", e.Name(), runErr, stderr.String())
+	}
+	if resp.HTML == "" {
+		return nil, errors.New("fetcher " + e.Name() + " returned no HTML")
+	}
+	if resp.URL == "" {
+		resp.URL = req.URL
+	}
+
+	return &resp, nil
+}
+
+func writeEmbeddedScript() (string, func(), error) {
+	tmpFile, err := os.CreateTemp("", "selenium_fetch_*.py")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create temp script: %w", err)
+	}
+	cleanup := func() { _ = os.Remove(tmpFile.Name()) }
+
+	if _, err := tmpFile.Write(embeddedSeleniumScript); err != nil {
+		_ = tmpFile.Close()
+		cleanup()
+		return "", nil, fmt.Errorf("failed to write embedded selenium script: %w", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		cleanup()
+		return "", nil, err
+	}
+
+	return tmpFile.Name(), cleanup, nil
+}
+
+// HTTPCookies converts fetcher cookies for use with a cookie jar.
+func HTTPCookies(in []Cookie) []*http.Cookie {
+	out := make([]*http.Cookie, 0, len(in))
+	for _, c := range in {
+		hc := &http.Cookie{
+			Name:     c.Name,
+			Value:    c.Value,
+			Domain:   c.Domain,
+			Path:     c.Path,
+			Secure:   c.Secure,
+			HttpOnly: c.HTTPOnly,
+		}
+		if c.Expires > 0 {
+			hc.Expires = time.Unix(c.Expires, 0)
+		}
+		out = append(out, hc)
+	}
+
+	return out
+}
+
+// FromHTTPCookies converts jar cookies into fetcher cookies.
+func FromHTTPCookies(in []*http.Cookie, domain string) []Cookie {
+	out := make([]Cookie, 0, len(in))
+	for _, c := range in {
+		out = append(out, Cookie{Name: c.Name, Value: c.Value, Domain: domain, Path: "/"})
+	}
+
+	return out
+}
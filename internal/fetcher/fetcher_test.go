@@ -0,0 +1,117 @@
+package fetcher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestStubFetcher is not a real test: it is the stub fetcher the other
+// tests run, by starting the test binary again with STUB_FETCHER set.
+func TestStubFetcher(t *testing.T) {
+	mode := os.Getenv("STUB_FETCHER")
+	if mode == "" {
+		t.Skip("only runs as a stub fetcher")
+	}
+
+	var req Request
+	if err := json.NewDecoder(os.Stdin).Decode(&req); err != nil {
+		fmt.Printf(`{"error": %q}`, err.Error())
+		os.Exit(1)
+	}
+
+	// Everything after "--" is what the fetcher command was given.
+	var args []string
+	for i, a := range os.Args {
+		if a == "--" {
+			args = os.Args[i+1:]
+			break
+		}
+	}
+
+	switch mode {
+	case "ok":
+		_ = json.NewEncoder(os.Stdout).Encode(Response{
+			HTML:    "<p>" + strings.Join(args, "|") + "</p>",
+			Cookies: []Cookie{{Name: "cf_clearance", Value: req.Headers["User-Agent"]}},
+		})
+	case "error":
+		fmt.Print(`{"error": "challenge not solved"}`)
+		os.Exit(1)
+	case "garbage":
+		fmt.Print("not json")
+	}
+	os.Exit(0)
+}
+
+func stub(t *testing.T, mode string, args ...string) *External {
+	t.Setenv("STUB_FETCHER", mode)
+	return New(append([]string{os.Args[0], "-test.run=^TestStubFetcher$", "--"}, args...))
+}
+
+func TestExternalFetch(t *testing.T) {
+	f := stub(t, "ok", "two words", "--flag")
+
+	resp, err := f.Fetch(context.Background(), Request{
+		URL:     "https://example.com/manga/1",
+		Headers: map[string]string{"User-Agent": "test-agent"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "<p>two words|--flag</p>"; resp.HTML != want {
+		t.Errorf("HTML = %q, want %q", resp.HTML, want)
+	}
+	if resp.URL != "https://example.com/manga/1" {
+		t.Errorf("URL = %q, want the request URL", resp.URL)
+	}
+	if len(resp.Cookies) != 1 || resp.Cookies[0].Value != "test-agent" {
+		t.Errorf("Cookies = %+v, want the request's User-Agent echoed back", resp.Cookies)
+	}
+}
+
+func TestExternalFetchErrors(t *testing.T) {
+	for mode, want := range map[string]string{
+		"error":   "challenge not solved",
+		"garbage": "invalid JSON",
+	} {
+		t.Run(mode, func(t *testing.T) {
+			_, err := stub(t, mode).Fetch(context.Background(), Request{URL: "https://example.com/"})
+			if err == nil || !strings.Contains(err.Error(), want) {
+				t.Fatalf("err = %v, want it to mention %q", err, want)
+			}
+		})
+	}
+}
+
+func TestSplitCommand(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want []string
+	}{
+		{"node fetch.js", []string{"node", "fetch.js"}},
+		{`  node   "my fetcher.js"  `, []string{"node", "my fetcher.js"}},
+		{`'/opt/my tools/fetch' --wait 3`, []string{"/opt/my tools/fetch", "--wait", "3"}},
+		{`/opt/my\ tools/fetch`, []string{"/opt/my tools/fetch"}},
+		{`"C:\Program Files\fetch.exe" -q`, []string{`C:\Program Files\fetch.exe`, "-q"}},
+		{`python3 fetch.py --label "say \"hi\"" ''`, []string{"python3", "fetch.py", "--label", `say "hi"`, ""}},
+		{"", nil},
+	} {
+		got, err := SplitCommand(tc.in)
+		if err != nil {
+			t.Errorf("SplitCommand(%q): %v", tc.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("SplitCommand(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+
+	if _, err := SplitCommand(`node "fetch.js`); err == nil {
+		t.Error(`SplitCommand(node "fetch.js) should fail on the unterminated quote`)
+	}
+}
@@ -0,0 +1,76 @@
+import json
+import sys
+
+from seleniumbase import Driver
+
+# mangad external fetcher protocol: one JSON request on stdin,
+# one JSON response on stdout.
+
+try:
+    req = json.load(sys.stdin)
+except Exception as e:
+    print(json.dumps({"error": f"invalid request: {e}"}))
+    sys.exit(1)
+
+url = req.get("url")
+if not url:
+    print(json.dumps({"error": "missing url"}))
+    sys.exit(1)
+
+headers = req.get("headers") or {}
+wait = req.get("wait") or {}
+
+driver = Driver(
+    uc=True,
+    headless=True,
+    proxy=req.get("proxy") or None,
+    agent=headers.get("User-Agent"),
+)
+
+try:
+    driver.uc_open_with_reconnect(url, 4)
+
+    cookies = req.get("cookies") or []
+    if cookies:
+        for c in cookies:
+            cookie = {"name": c["name"], "value": c["value"], "path": c.get("path") or "/"}
+            if c.get("domain"):
+                cookie["domain"] = c["domain"]
+            try:
+                driver.add_cookie(cookie)
+            except Exception:
+                pass
+        driver.uc_open_with_reconnect(url, 4)
+
+    driver.uc_gui_click_captcha()
+
+    if wait.get("selector"):
+        driver.wait_for_element(wait["selector"], timeout=wait.get("timeout") or 30)
+    if wait.get("seconds"):
+        driver.sleep(wait["seconds"])
+
+    resp = {
+        "html": driver.page_source,
+        "url": driver.current_url,
+        "user_agent": driver.execute_script("return navigator.userAgent"),
+        "cookies": [
+            {
+                "name": c.get("name"),
+                "value": c.get("value"),
+                "domain": c.get("domain", ""),
+                "path": c.get("path", "/"),
+                "expires": int(c.get("expiry", 0)),
+                "secure": bool(c.get("secure", False)),
+                "http_only": bool(c.get("httpOnly", False)),
+            }
+            for c in driver.get_cookies()
+        ],
+    }
+    driver.quit()
+    print(json.dumps(resp))
+    sys.exit(0)
+except Exception as e:
+    driver.quit()
+    print(json.dumps({"error": str(e)}))
+    print(f"ERROR: {e}", file=sys.stderr)
+    sys.exit(1)
@@ -0,0 +1,5 @@
+// Package imgfmt describes the page image formats mangad knows about: their
+// file extensions, MIME types and file signatures. The allow_ext setting is
+// resolved against this table, so adding a format here is enough for the
+// scraper and the downloader to accept it.
+package imgfmt
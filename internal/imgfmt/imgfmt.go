@@ -0,0 +1,174 @@
+package imgfmt
+
+import (
+	"bytes"
+	"mime"
+	"sort"
+	"strings"
+)
+
+// Format is one image format.
+type Format struct {
+	Name string   // canonical extension without the dot, "jpg"
+	Exts []string // every extension used for the format, canonical first
+	MIME []string // media types, canonical first
+	sig  func(head []byte) bool
+}
+
+// Ext is the canonical extension with the dot, ".jpg".
+func (f Format) Ext() string {
+	return "." + f.Name
+}
+
+// Formats are the known page formats.
+var Formats = []Format{
+	{Name: "jpg", Exts: []string{"jpg", "jpeg", "jpe", "jfif"}, MIME: []string{"image/jpeg", "image/pjpeg"}, sig: isJPEG},
+	{Name: "png", Exts: []string{"png", "apng"}, MIME: []string{"image/png", "image/apng"}, sig: isPNG},
+	{Name: "webp", Exts: []string{"webp"}, MIME: []string{"image/webp"}, sig: isWebP},
+	{Name: "gif", Exts: []string{"gif"}, MIME: []string{"image/gif"}, sig: isGIF},
+	{Name: "avif", Exts: []string{"avif"}, MIME: []string{"image/avif"}, sig: isAVIF},
+	{Name: "jxl", Exts: []string{"jxl"}, MIME: []string{"image/jxl"}, sig: isJXL},
+}
+
+// ByExt finds the format of a file extension, with or without the dot.
+func ByExt(ext string) (Format, bool) {
+	ext = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(ext), "."))
+	for _, f := range Formats {
+		for _, e := range f.Exts {
+			if e == ext {
+				return f, true
+			}
+		}
+	}
+
+	return Format{}, false
+}
+
+// ByMIME finds the format of a Content-Type header value.
+func ByMIME(contentType string) (Format, bool) {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return Format{}, false
+	}
+	for _, f := range Formats {
+		for _, m := range f.MIME {
+			if m == mt {
+				return f, true
+			}
+		}
+	}
+
+	return Format{}, false
+}
+
+// Sniff finds the format from the first bytes of a file. 32 bytes are
+// enough for every known format.
+func Sniff(head []byte) (Format, bool) {
+	for _, f := range Formats {
+		if f.sig(head) {
+			return f, true
+		}
+	}
+
+	return Format{}, false
+}
+
+func isJPEG(b []byte) bool {
+	return len(b) >= 3 && b[0] == 0xff && b[1] == 0xd8 && b[2] == 0xff
+}
+
+func isPNG(b []byte) bool {
+	return bytes.HasPrefix(b, []byte("\x89PNG\r\n\x1a\n"))
+}
+
+func isGIF(b []byte) bool {
+	return bytes.HasPrefix(b, []byte("GIF87a")) || bytes.HasPrefix(b, []byte("GIF89a"))
+}
+
+func isWebP(b []byte) bool {
+	return len(b) >= 12 && string(b[0:4]) == "RIFF" && string(b[8:12]) == "WEBP"
+}
+
+// isAVIF looks for an avif or avis brand in the leading ISO-BMFF ftyp box.
+func isAVIF(b []byte) bool {
+	if len(b) < 16 || string(b[4:8]) != "ftyp" {
+		return false
+	}
+
+	size := int(b[0])<<24 | int(b[1])<<16 | int(b[2])<<8 | int(b[3])
+	size = min(size, len(b))
+	for i := 8; i+4 <= size; i += 4 {
+		if brand := string(b[i : i+4]); brand == "avif" || brand == "avis" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isJXL matches both the bare codestream and the ISO-BMFF container.
+func isJXL(b []byte) bool {
+	return bytes.HasPrefix(b, []byte{0xff, 0x0a}) ||
+		bytes.HasPrefix(b, []byte("\x00\x00\x00\x0cJXL \r\n\x87\n"))
+}
+
+// Set is the formats allowed by allow_ext. Listing one extension of a known
+// format allows all of its extensions ("jpg" also allows "jpeg"); unknown
+// extensions are matched in URLs only.
+type Set struct {
+	exts    map[string]bool
+	formats map[string]bool // by Format.Name
+}
+
+func NewSet(exts []string) Set {
+	s := Set{exts: map[string]bool{}, formats: map[string]bool{}}
+	for _, ext := range exts {
+		ext = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(ext), "."))
+		if ext == "" {
+			continue
+		}
+
+		s.exts[ext] = true
+		if f, ok := ByExt(ext); ok {
+			s.formats[f.Name] = true
+			for _, e := range f.Exts {
+				s.exts[e] = true
+			}
+		}
+	}
+
+	return s
+}
+
+// Extensions lists every extension the set accepts in URLs, without dots.
+func (s Set) Extensions() []string {
+	out := make([]string, 0, len(s.exts))
+	for e := range s.exts {
+		out = append(out, e)
+	}
+	sort.Strings(out)
+
+	return out
+}
+
+// AllowsExt reports whether URLs ending in ext are accepted.
+func (s Set) AllowsExt(ext string) bool {
+	return s.exts[strings.ToLower(strings.TrimPrefix(ext, "."))]
+}
+
+// Allows reports whether f is one of the allowed formats.
+func (s Set) Allows(f Format) bool {
+	return s.formats[f.Name]
+}
+
+// Names lists the allowed known formats, for messages.
+func (s Set) Names() []string {
+	var out []string
+	for _, f := range Formats {
+		if s.formats[f.Name] {
+			out = append(out, f.Name)
+		}
+	}
+
+	return out
+}
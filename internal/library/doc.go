@@ -0,0 +1,4 @@
+// Package library writes the per-series files library servers such as
+// Komga, Kavita and Jellyfin read next to the CBZs: series.json in the
+// Mylar3 schema and cover.jpg.
+package library
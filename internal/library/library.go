@@ -0,0 +1,141 @@
+package library
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"image"
+	_ "image/gif" // decoders for covers that are not JPEG
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/brogergvhs/mangad/internal/providers"
+	"github.com/brogergvhs/mangad/internal/util"
+	_ "golang.org/x/image/webp"
+)
+
+// SeriesFile and CoverFile are written to the series folder.
+const (
+	SeriesFile = "series.json"
+	CoverFile  = "cover.jpg"
+)
+
+// mylarSeries is the series.json layout of Mylar3 (schema 1.0.2), which
+// Komga and Kavita import.
+type mylarSeries struct {
+	Version  string        `json:"version"`
+	Metadata mylarMetadata `json:"metadata"`
+}
+
+type mylarMetadata struct {
+	Type                 string  `json:"type"`
+	Publisher            string  `json:"publisher"`
+	Imprint              *string `json:"imprint"`
+	Name                 string  `json:"name"`
+	ComicID              *int    `json:"comicid"`
+	Year                 *int    `json:"year"`
+	DescriptionText      string  `json:"description_text"`
+	DescriptionFormatted *string `json:"description_formatted"`
+	Volume               *int    `json:"volume"`
+	BookType             string  `json:"booktype"`
+	AgeRating            *string `json:"age_rating"`
+	Collects             *string `json:"collects"`
+	ComicImage           string  `json:"ComicImage"`
+	TotalIssues          int     `json:"total_issues"`
+	PublicationRun       string  `json:"publication_run"`
+	Status               string  `json:"status"`
+}
+
+// WriteSeries writes series.json for the series to dir. name is the series
+// name used for the folder, which may differ from the scraped title.
+// chapters is the full chapter list, used for the issue count and the
+// publication run.
+func WriteSeries(dir, name string, info providers.Series, chapters []providers.Chapter) error {
+	meta := mylarMetadata{
+		Type:            "comicSeries",
+		Name:            name,
+		DescriptionText: info.Description,
+		BookType:        "Print",
+		ComicImage:      info.Cover,
+		TotalIssues:     len(chapters),
+		Status:          "Continuing",
+	}
+
+	var first time.Time
+	for _, ch := range chapters {
+		if !ch.Date.IsZero() && (first.IsZero() || ch.Date.Before(first)) {
+			first = ch.Date
+		}
+	}
+	if !first.IsZero() {
+		year := first.Year()
+		meta.Year = &year
+		meta.PublicationRun = fmt.Sprintf("%s - Present", first.Format("January 2006"))
+	}
+
+	b, err := json.MarshalIndent(mylarSeries{Version: "1.0.2", Metadata: meta}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(dir, SeriesFile), append(b, '\n'), 0644)
+}
+
+// SaveCover downloads the cover to dir/cover.jpg unless it already exists.
+// PNG and GIF covers are converted; formats the standard library can't
+// decode are an error.
+func SaveCover(ctx context.Context, client *http.Client, coverURL, referer, dir string) error {
+	out := filepath.Join(dir, CoverFile)
+	if _, err := os.Stat(out); err == nil {
+		return nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", coverURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Referer", referer)
+	req.Header.Set("Accept", "image/jpeg,image/png,image/*;q=0.8")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return &util.HTTPError{Status: resp.StatusCode, URL: coverURL}
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 32<<20))
+	if err != nil {
+		return err
+	}
+
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("unsupported cover image: %w", err)
+	}
+	if format != "jpeg" {
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 90}); err != nil {
+			return err
+		}
+		data = buf.Bytes()
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(out, data, 0644)
+}
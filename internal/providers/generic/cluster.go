@@ -0,0 +1,191 @@
+package generic
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// minClusterSize is the smallest link cluster trusted to be the chapter
+// list. Below it all chapter-like links are kept as before.
+const minClusterSize = 3
+
+// chapterCandidate is an anchor that passed looksLikeChapterLink and
+// parseChapterLabel, before the structural pass.
+type chapterCandidate struct {
+	a       *goquery.Selection
+	label   chapterLabel
+	url     string
+	link    int // position in the inspect link list, -1 when not tracing
+	cluster string
+}
+
+type linkCluster struct {
+	key       string
+	container string
+	members   []int
+	score     float64
+}
+
+// pickChapterCluster groups candidates by the structure of their container
+// and their URL directory, scores each group and returns the candidates of
+// the dominant one. Groups with the same container path and URL directory
+// (e.g. one <ul> per volume) merge naturally.
+func pickChapterCluster(cands []chapterCandidate, pageURL string) ([]int, *linkCluster) {
+	all := make([]int, len(cands))
+	for i := range all {
+		all[i] = i
+	}
+	if len(cands) < minClusterSize {
+		return all, nil
+	}
+
+	byKey := map[string]*linkCluster{}
+	var order []*linkCluster
+
+	for i := range cands {
+		container := containerSignature(chapterRow(cands[i].a).Parent())
+		key := container + " | " + urlDir(cands[i].url)
+		cands[i].cluster = key
+
+		c, ok := byKey[key]
+		if !ok {
+			c = &linkCluster{key: key, container: container}
+			byKey[key] = c
+			order = append(order, c)
+		}
+		c.members = append(c.members, i)
+	}
+
+	slug := seriesSlug(pageURL)
+
+	var best *linkCluster
+	for _, c := range order {
+		c.score = float64(len(c.members)) *
+			(0.25 + numericProgression(cands, c.members)) *
+			(0.25 + sameSeriesShare(cands, c.members, slug))
+
+		if best == nil || c.score > best.score {
+			best = c
+		}
+	}
+
+	if len(order) == 1 || len(best.members) < minClusterSize {
+		return all, nil
+	}
+
+	return best.members, best
+}
+
+// numericProgression is the share of neighbouring links (in page order)
+// whose chapter numbers step by a small amount in a consistent direction.
+func numericProgression(cands []chapterCandidate, members []int) float64 {
+	if len(members) < 2 {
+		return 0
+	}
+
+	up, down := 0, 0
+	for i := 1; i < len(members); i++ {
+		d := cands[members[i]].label.Number - cands[members[i-1]].label.Number
+		switch {
+		case d > 0 && d <= 2:
+			up++
+		case d < 0 && d >= -2:
+			down++
+		}
+	}
+
+	return float64(max(up, down)) / float64(len(members)-1)
+}
+
+// sameSeriesShare is the share of links whose path contains the series
+// slug of the page URL. Pages without a slug count as a full match.
+func sameSeriesShare(cands []chapterCandidate, members []int, slug string) float64 {
+	if slug == "" {
+		return 1
+	}
+
+	n := 0
+	for _, m := range members {
+		if strings.Contains(strings.ToLower(cands[m].url), slug) {
+			n++
+		}
+	}
+
+	return float64(n) / float64(len(members))
+}
+
+func seriesSlug(pageURL string) string {
+	u, err := url.Parse(pageURL)
+	if err != nil {
+		return ""
+	}
+
+	slug := strings.ToLower(path.Base(strings.TrimRight(u.Path, "/")))
+	if slug == "." || slug == "/" || len(slug) < 3 {
+		return ""
+	}
+
+	return slug
+}
+
+// urlDir is the folder a chapter URL lives in; "/manga/foo/chapter-1/"
+// and "/manga/foo/chapter-1" both give "/manga/foo".
+func urlDir(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+
+	return u.Host + path.Dir(strings.TrimRight(u.Path, "/"))
+}
+
+// containerSignature describes an element by its tag/id/class path, e.g.
+// "div#content > ul.chapter-list". Ids and classes with digits are skipped
+// since they usually differ per item.
+func containerSignature(sel *goquery.Selection) string {
+	var parts []string
+	for n := sel; n.Length() > 0; n = n.Parent() {
+		name := goquery.NodeName(n)
+		if name == "body" || name == "html" || name == "#document" {
+			break
+		}
+		parts = append(parts, nodeSignature(n, name))
+	}
+	slices.Reverse(parts)
+
+	if len(parts) == 0 {
+		return "body"
+	}
+
+	return strings.Join(parts, " > ")
+}
+
+func nodeSignature(n *goquery.Selection, name string) string {
+	sig := name
+	if id, ok := n.Attr("id"); ok && id != "" && !strings.ContainsAny(id, "0123456789") {
+		sig += "#" + id
+	}
+
+	classes := strings.Fields(n.AttrOr("class", ""))
+	slices.Sort(classes)
+	kept := 0
+	for _, c := range classes {
+		if kept == 2 || strings.ContainsAny(c, "0123456789") {
+			continue
+		}
+		sig += "." + c
+		kept++
+	}
+
+	return sig
+}
+
+func (c *linkCluster) String() string {
+	return fmt.Sprintf("%s (%d links, score %.1f)", c.key, len(c.members), math.Round(c.score*10)/10)
+}
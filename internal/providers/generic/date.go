@@ -0,0 +1,146 @@
+package generic
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+var (
+	reRelativeDate = regexp.MustCompile(`(?i)\b(an?|\d+)\s*(sec(?:ond)?|min(?:ute)?|h(?:ou)?r|day|week|month|year)s?\s+ago\b`)
+	reDayWord      = regexp.MustCompile(`(?i)\b(just now|today|yesterday)\b`)
+
+	reISODate   = regexp.MustCompile(`\b(\d{4})[-/.](\d{1,2})[-/.](\d{1,2})\b`)
+	reNumDate   = regexp.MustCompile(`\b(\d{1,2})[-/.](\d{1,2})[-/.](\d{4})\b`)
+	reMonthDate = regexp.MustCompile(`(?i)\b(?:(\d{1,2})(?:st|nd|rd|th)?\s+)?(jan|feb|mar|apr|may|jun|jul|aug|sep|oct|nov|dec)[a-z]*\.?\s*(?:(\d{1,2})(?:st|nd|rd|th)?)?,?\s+(\d{4})\b`)
+
+	months = map[string]time.Month{
+		"jan": time.January, "feb": time.February, "mar": time.March, "apr": time.April,
+		"may": time.May, "jun": time.June, "jul": time.July, "aug": time.August,
+		"sep": time.September, "oct": time.October, "nov": time.November, "dec": time.December,
+	}
+)
+
+// dateAttrSel finds elements that carry a machine-readable date.
+const dateAttrSel = "time, [datetime], [data-date], [data-time], [data-timestamp]"
+
+// extractDate looks for a release date next to a chapter link: <time> and
+// data attributes first, then absolute or relative dates in the row text.
+// It returns the zero time when nothing is found.
+func extractDate(a *goquery.Selection, now time.Time) time.Time {
+	row := chapterRow(a)
+
+	var found time.Time
+	row.Find(dateAttrSel).EachWithBreak(func(_ int, el *goquery.Selection) bool {
+		for _, attr := range []string{"datetime", "data-date", "data-time", "data-timestamp", "title"} {
+			if v, ok := el.Attr(attr); ok {
+				if found = parseDate(v, now); !found.IsZero() {
+					return false
+				}
+			}
+		}
+		found = parseDate(el.Text(), now)
+		return found.IsZero()
+	})
+	if !found.IsZero() {
+		return found
+	}
+
+	return parseDate(row.Text(), now)
+}
+
+// parseDate understands ISO timestamps, unix timestamps, "2024-01-15",
+// "15/01/2024", "Jan 15, 2024", "15 January 2024" and relative strings such
+// as "3 days ago" or "yesterday".
+func parseDate(s string, now time.Time) time.Time {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}
+	}
+
+	for _, layout := range []string{time.RFC3339, time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02 15:04"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil && n > 1e9 {
+		if n > 1e12 {
+			return time.UnixMilli(n).UTC()
+		}
+		return time.Unix(n, 0).UTC()
+	}
+
+	if m := reISODate.FindStringSubmatch(s); m != nil {
+		return makeDate(atoi(m[1]), atoi(m[2]), atoi(m[3]))
+	}
+	if m := reMonthDate.FindStringSubmatch(s); m != nil {
+		day := m[1]
+		if day == "" {
+			day = m[3]
+		}
+		if day == "" {
+			day = "1"
+		}
+		return makeDate(atoi(m[4]), int(months[strings.ToLower(m[2][:3])]), atoi(day))
+	}
+	if m := reNumDate.FindStringSubmatch(s); m != nil {
+		// day first unless that can't be right (US style 01/31/2024)
+		d, mo := atoi(m[1]), atoi(m[2])
+		if mo > 12 && d <= 12 {
+			d, mo = mo, d
+		}
+		return makeDate(atoi(m[3]), mo, d)
+	}
+
+	if m := reRelativeDate.FindStringSubmatch(s); m != nil {
+		n := 1
+		if !strings.HasPrefix(strings.ToLower(m[1]), "a") {
+			n = atoi(m[1])
+		}
+		return relativeDate(now, n, strings.ToLower(m[2]))
+	}
+	if m := reDayWord.FindStringSubmatch(s); m != nil {
+		day := now.Truncate(24 * time.Hour)
+		if strings.EqualFold(m[1], "yesterday") {
+			return day.AddDate(0, 0, -1)
+		}
+		return day
+	}
+
+	return time.Time{}
+}
+
+func relativeDate(now time.Time, n int, unit string) time.Time {
+	switch {
+	case strings.HasPrefix(unit, "s"):
+		return now.Add(-time.Duration(n) * time.Second)
+	case strings.HasPrefix(unit, "mi"):
+		return now.Add(-time.Duration(n) * time.Minute)
+	case strings.HasPrefix(unit, "h"):
+		return now.Add(-time.Duration(n) * time.Hour)
+	case unit == "day":
+		return now.AddDate(0, 0, -n)
+	case unit == "week":
+		return now.AddDate(0, 0, -7*n)
+	case unit == "month":
+		return now.AddDate(0, -n, 0)
+	default:
+		return now.AddDate(-n, 0, 0)
+	}
+}
+
+func makeDate(y, m, d int) time.Time {
+	if y < 1990 || m < 1 || m > 12 || d < 1 || d > 31 {
+		return time.Time{}
+	}
+
+	return time.Date(y, time.Month(m), d, 0, 0, 0, 0, time.UTC)
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
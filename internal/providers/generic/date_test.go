@@ -0,0 +1,62 @@
+package generic
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDate(t *testing.T) {
+	now := time.Date(2025, time.June, 15, 12, 30, 0, 0, time.UTC)
+	day := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 0, 0, 0, 0, time.UTC) }
+
+	for _, tc := range []struct {
+		in   string
+		want time.Time
+	}{
+		// timestamps
+		{"2024-01-15T10:20:30Z", time.Date(2024, time.January, 15, 10, 20, 30, 0, time.UTC)},
+		{"2024-01-15T10:20:30.5+02:00", time.Date(2024, time.January, 15, 8, 20, 30, 5e8, time.UTC)},
+		{"2024-01-15T10:20:30", time.Date(2024, time.January, 15, 10, 20, 30, 0, time.UTC)},
+		{"2024-01-15 10:20", time.Date(2024, time.January, 15, 10, 20, 0, 0, time.UTC)},
+		{"1705314000", time.Unix(1705314000, 0).UTC()},
+		{"1705314000123", time.UnixMilli(1705314000123).UTC()},
+
+		// absolute dates
+		{"2024-01-15", day(2024, time.January, 15)},
+		{"2024/1/5", day(2024, time.January, 5)},
+		{"Released 2024.01.15 by Team", day(2024, time.January, 15)},
+		{"15/01/2024", day(2024, time.January, 15)},
+		{"05/06/2024", day(2024, time.June, 5)},
+		{"01/31/2024", day(2024, time.January, 31)},
+		{"Jan 15, 2024", day(2024, time.January, 15)},
+		{"January 15th, 2024", day(2024, time.January, 15)},
+		{"15 January 2024", day(2024, time.January, 15)},
+		{"2nd Sept. 2023", day(2023, time.September, 2)},
+		{"March 2024", day(2024, time.March, 1)},
+
+		// relative dates
+		{"just now", day(2025, time.June, 15)},
+		{"Today", day(2025, time.June, 15)},
+		{"yesterday", day(2025, time.June, 14)},
+		{"10 seconds ago", now.Add(-10 * time.Second)},
+		{"5 mins ago", now.Add(-5 * time.Minute)},
+		{"an hour ago", now.Add(-time.Hour)},
+		{"2 hrs ago", now.Add(-2 * time.Hour)},
+		{"3 days ago", day(2025, time.June, 12).Add(12*time.Hour + 30*time.Minute)},
+		{"a week ago", now.AddDate(0, 0, -7)},
+		{"2 months ago", now.AddDate(0, -2, 0)},
+		{"1 year ago", now.AddDate(-1, 0, 0)},
+		{"Chapter 12 - 4 weeks ago", now.AddDate(0, 0, -28)},
+
+		// nothing usable
+		{"", time.Time{}},
+		{"Chapter 12", time.Time{}},
+		{"1985-01-01", time.Time{}},
+		{"2024-13-01", time.Time{}},
+		{"12345", time.Time{}},
+	} {
+		if got := parseDate(tc.in, now); !got.Equal(tc.want) {
+			t.Errorf("parseDate(%q) = %v, want %v", tc.in, got, tc.want)
+		}
+	}
+}
@@ -41,6 +41,7 @@ func (s *Scraper) probeDynamicEndpoints(
 	js JSAnalysis,
 	col *imageCollector,
 ) {
+	defer col.enter("DynamicEndpoints")()
 
 	candidates := tryBuildDynamicURLs(js)
 	s.log.Debugf("Dynamic endpoint candidates: %v\n", candidates)
@@ -48,7 +49,7 @@ func (s *Scraper) probeDynamicEndpoints(
 	for _, path := range candidates {
 		fullURL := resolve(chapterURL, path)
 
-		s.log.Debugf("Probing dynamic:", fullURL)
+		s.log.Debug("Probing dynamic endpoint", "url", fullURL)
 
 		html, ok := s.tryDynamicFetch(ctx, fullURL, "POST")
 		if !ok {
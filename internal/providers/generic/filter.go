@@ -0,0 +1,322 @@
+package generic
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"image"
+	_ "image/gif"  // register decoder for DecodeConfig
+	_ "image/jpeg" // register decoder for DecodeConfig
+	_ "image/png"  // register decoder for DecodeConfig
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+const (
+	probeBytes   = 64 << 10
+	probeWorkers = 8
+
+	// minPageSide: pages narrower AND shorter than this are icons/avatars.
+	minPageSide = 200
+	// maxBannerHeight/bannerRatio describe leaderboard-style ad banners
+	// (728x90, 970x250, ...). Webtoon slices are tall, not wide.
+	maxBannerHeight = 300
+	bannerRatio     = 3.0
+	// dominantShare is the share of candidates a host needs before
+	// outliers on other hosts are dropped.
+	dominantShare = 0.6
+	minorShare    = 0.2
+)
+
+var (
+	adHosts = regexp.MustCompile(`(?i)(?:^|\.)(?:doubleclick\.net|googlesyndication\.com|googleadservices\.com|adservice\.google\.[a-z.]+|amazon-adsystem\.com|adnxs\.com|taboola\.com|outbrain\.com|popads\.net|propellerads\.com|exoclick\.com|juicyads\.com|gravatar\.com|facebook\.com|fbcdn\.net|twitter\.com|twimg\.com|discord(?:app)?\.com|patreon\.com|ko-fi\.com)$`)
+
+	// adFolder matches a folder named after ads or site chrome ("/ads/",
+	// "/banners2/"); adFile finds such a word in a file name. Folders only
+	// count as a whole, so series slugs like "social-circle" or "ad-astra"
+	// are not mistaken for ads.
+	adFolder = regexp.MustCompile(`(?i)^(?:` + adWords + `)\d*$`)
+	adFile   = regexp.MustCompile(`(?i)(?:^|[_\-.])(` + adWords + `)(?:[_\-.]|\d|$)`)
+)
+
+const adWords = `ads?|adv|advert(?:isement)?s?|banners?|sponsors?|promo(?:tion)?s?|avatars?|emojis?|favicon|icons?|thumbs?|thumbnails?|recommend(?:ed|ations)?|related|social|share`
+
+// droppedImage is a candidate removed by the filtering stage.
+type droppedImage struct {
+	URL    string
+	Reason string
+}
+
+// imageFilter removes ads, site banners, credit/recommendation thumbnails
+// and user-blocked URLs from the collected candidates.
+type imageFilter struct {
+	client  *http.Client
+	block   []*regexp.Regexp
+	probe   bool
+	referer string
+}
+
+func compileBlockPatterns(patterns []string) ([]*regexp.Regexp, error) {
+	out := make([]*regexp.Regexp, 0, len(patterns))
+	for _, p := range patterns {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+
+		re, err := regexp.Compile("(?i)" + p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid block pattern %q: %w", p, err)
+		}
+		out = append(out, re)
+	}
+
+	return out, nil
+}
+
+// Apply runs the heuristics in order of cost: patterns, host/path, host
+// dominance, and finally (when enabled) a header-only dimension probe.
+func (f *imageFilter) Apply(ctx context.Context, urls []string) ([]string, []droppedImage) {
+	var dropped []droppedImage
+
+	kept := make([]string, 0, len(urls))
+	for _, u := range urls {
+		if reason := f.matchStatic(u); reason != "" {
+			dropped = append(dropped, droppedImage{URL: u, Reason: reason})
+			continue
+		}
+		kept = append(kept, u)
+	}
+
+	kept, d := dropHostOutliers(kept)
+	dropped = append(dropped, d...)
+
+	if f.probe && len(kept) > 0 {
+		kept, d = f.dropBySize(ctx, kept)
+		dropped = append(dropped, d...)
+	}
+
+	return kept, dropped
+}
+
+func (f *imageFilter) matchStatic(u string) string {
+	for _, re := range f.block {
+		if re.MatchString(u) {
+			return "block pattern " + re.String()[4:]
+		}
+	}
+
+	pu, err := url.Parse(u)
+	if err != nil {
+		return ""
+	}
+	if adHosts.MatchString(pu.Hostname()) {
+		return "ad/social host " + pu.Hostname()
+	}
+	if w := adPathWord(pu.Path); w != "" {
+		return "ad/banner path " + w
+	}
+
+	return ""
+}
+
+// adPathWord returns the ad keyword of an image path: a folder named after
+// it, or a word of the file name that isn't also a word of the folders
+// (pages are often named after the series: social-circle-01.jpg).
+func adPathWord(p string) string {
+	dir, file := path.Split(p)
+
+	folderWords := map[string]bool{}
+	for _, seg := range strings.Split(strings.Trim(dir, "/"), "/") {
+		if adFolder.MatchString(seg) {
+			return seg
+		}
+		for _, w := range strings.FieldsFunc(strings.ToLower(seg), func(r rune) bool { return r == '-' || r == '_' || r == '.' }) {
+			folderWords[w] = true
+		}
+	}
+
+	for _, m := range adFile.FindAllStringSubmatch(file, -1) {
+		if w := strings.ToLower(m[1]); !folderWords[w] {
+			return w
+		}
+	}
+
+	return ""
+}
+
+// siteOf reduces a host to its last two labels so cdn1/cdn2 mirrors of the
+// same site count as one host.
+func siteOf(u string) string {
+	pu, err := url.Parse(u)
+	if err != nil {
+		return ""
+	}
+
+	labels := strings.Split(pu.Hostname(), ".")
+	if len(labels) > 2 {
+		labels = labels[len(labels)-2:]
+	}
+
+	return strings.Join(labels, ".")
+}
+
+// dropHostOutliers drops images served from hosts that hold only a small
+// share of the candidates when one host clearly dominates.
+func dropHostOutliers(urls []string) ([]string, []droppedImage) {
+	if len(urls) < 4 {
+		return urls, nil
+	}
+
+	counts := map[string]int{}
+	for _, u := range urls {
+		counts[siteOf(u)]++
+	}
+
+	dominant, top := "", 0
+	for h, n := range counts {
+		if n > top {
+			dominant, top = h, n
+		}
+	}
+	if float64(top)/float64(len(urls)) < dominantShare {
+		return urls, nil
+	}
+
+	var dropped []droppedImage
+	kept := make([]string, 0, len(urls))
+	for _, u := range urls {
+		h := siteOf(u)
+		if h != dominant && float64(counts[h])/float64(len(urls)) < minorShare {
+			dropped = append(dropped, droppedImage{URL: u, Reason: fmt.Sprintf("host %s differs from dominant image host %s", h, dominant)})
+			continue
+		}
+		kept = append(kept, u)
+	}
+
+	return kept, dropped
+}
+
+func (f *imageFilter) dropBySize(ctx context.Context, urls []string) ([]string, []droppedImage) {
+	reasons := make([]string, len(urls))
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(probeWorkers, len(urls)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				w, h, err := f.probeSize(ctx, urls[i])
+				if err != nil {
+					continue // unknown size is not a reason to drop
+				}
+				reasons[i] = sizeReason(w, h)
+			}
+		}()
+	}
+	for i := range urls {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	var dropped []droppedImage
+	kept := make([]string, 0, len(urls))
+	for i, u := range urls {
+		if reasons[i] != "" {
+			dropped = append(dropped, droppedImage{URL: u, Reason: reasons[i]})
+			continue
+		}
+		kept = append(kept, u)
+	}
+
+	return kept, dropped
+}
+
+func sizeReason(w, h int) string {
+	switch {
+	case w <= 0 || h <= 0:
+		return ""
+	case w < minPageSide && h < minPageSide:
+		return fmt.Sprintf("too small (%dx%d)", w, h)
+	case h < maxBannerHeight && float64(w)/float64(h) >= bannerRatio:
+		return fmt.Sprintf("banner aspect ratio (%dx%d)", w, h)
+	}
+
+	return ""
+}
+
+// probeSize fetches only the first bytes of an image and decodes its header.
+func (f *imageFilter) probeSize(ctx context.Context, u string) (int, int, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", u, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=0-%d", probeBytes-1))
+	req.Header.Set("Referer", f.referer)
+	req.Header.Set("Accept", "image/avif,image/webp,image/apng,image/*,*/*;q=0.8")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
+		return 0, 0, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	head, err := io.ReadAll(io.LimitReader(resp.Body, probeBytes))
+	if err != nil && len(head) == 0 {
+		return 0, 0, err
+	}
+
+	return imageDims(head)
+}
+
+// imageDims decodes width/height from the leading bytes of an image.
+func imageDims(head []byte) (int, int, error) {
+	if w, h, ok := webpDims(head); ok {
+		return w, h, nil
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(head))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return cfg.Width, cfg.Height, nil
+}
+
+// webpDims parses the VP8/VP8L/VP8X headers; the standard library has no
+// WebP decoder.
+func webpDims(b []byte) (int, int, bool) {
+	if len(b) < 30 || string(b[0:4]) != "RIFF" || string(b[8:12]) != "WEBP" {
+		return 0, 0, false
+	}
+
+	switch string(b[12:16]) {
+	case "VP8 ":
+		w := int(binary.LittleEndian.Uint16(b[26:28]) & 0x3fff)
+		h := int(binary.LittleEndian.Uint16(b[28:30]) & 0x3fff)
+		return w, h, true
+	case "VP8L":
+		b0, b1, b2, b3 := int(b[21]), int(b[22]), int(b[23]), int(b[24])
+		w := 1 + (((b1 & 0x3f) << 8) | b0)
+		h := 1 + (((b3 & 0x0f) << 10) | (b2 << 2) | ((b1 & 0xc0) >> 6))
+		return w, h, true
+	case "VP8X":
+		w := 1 + (int(b[24]) | int(b[25])<<8 | int(b[26])<<16)
+		h := 1 + (int(b[27]) | int(b[28])<<8 | int(b[29])<<16)
+		return w, h, true
+	}
+
+	return 0, 0, false
+}
@@ -0,0 +1,98 @@
+package generic
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/brogergvhs/mangad/internal/providers"
+)
+
+const (
+	chapterRowSel = "li, tr, article, [class*=chapter], [class*=episode]"
+	groupSel      = "[class*=group], [class*=scanlat], [class*=team], [class*=translator], " +
+		"a[href*='/group/'], a[href*='/groups/'], a[href*='/team/'], a[href*='/scanlator']"
+)
+
+var (
+	reFlagClass = regexp.MustCompile(`(?i)(?:^|\s)(?:flag-icon-|flag-|fi-|lang-)([a-z]{2}(?:-[a-z]{2})?)(?:\s|$)`)
+	rePathLang  = regexp.MustCompile(`(?i)/(en|es|es-la|fr|de|it|pt|pt-br|ru|id|vi|th|tr|pl|ar|ja|ko|zh|zh-hk)/`)
+)
+
+// maxRowLinks is the most links an element may hold to still count as one
+// chapter entry rather than the whole list.
+const maxRowLinks = 4
+
+// chapterRow returns the closest element that plausibly holds one chapter
+// entry (list item, table row, card) around a chapter link, or the link
+// itself when its surroundings hold other chapters too.
+func chapterRow(a *goquery.Selection) *goquery.Selection {
+	if row := a.Parent().Closest(chapterRowSel); row.Length() > 0 && row.Find("a[href]").Length() <= maxRowLinks {
+		return row
+	}
+	if p := a.Parent(); p.Length() > 0 && p.Find("a[href]").Length() <= maxRowLinks {
+		return p
+	}
+
+	return a
+}
+
+// extractGroupLang pulls scanlation group and language hints from a chapter
+// link and its surrounding row. Either value may be empty.
+func extractGroupLang(a *goquery.Selection, href string) (group, lang string) {
+	row := chapterRow(a)
+
+	if g := row.Find(groupSel).NotSelection(a).First(); g.Length() > 0 {
+		group = cleanText(g.Text())
+		if group == "" {
+			group = strings.TrimSpace(g.AttrOr("title", ""))
+		}
+	}
+
+	lang = langFromSelection(a)
+	if lang == "" {
+		lang = langFromSelection(row)
+	}
+	if lang == "" {
+		row.Find("[data-lang], [lang], [hreflang], [class*=flag], img[alt]").EachWithBreak(func(_ int, el *goquery.Selection) bool {
+			lang = langFromSelection(el)
+			return lang == ""
+		})
+	}
+	if lang == "" {
+		if u, err := url.Parse(href); err == nil {
+			if v := u.Query().Get("lang"); v != "" {
+				lang = providers.NormalizeLang(v)
+			} else if m := rePathLang.FindStringSubmatch(u.Path + "/"); m != nil {
+				lang = providers.NormalizeLang(m[1])
+			}
+		}
+	}
+
+	return group, lang
+}
+
+func langFromSelection(el *goquery.Selection) string {
+	for _, attr := range []string{"data-lang", "data-language", "hreflang", "lang"} {
+		if v, ok := el.Attr(attr); ok && strings.TrimSpace(v) != "" {
+			return providers.NormalizeLang(v)
+		}
+	}
+
+	if cls, ok := el.Attr("class"); ok {
+		if m := reFlagClass.FindStringSubmatch(cls); m != nil {
+			return providers.NormalizeLang(m[1])
+		}
+	}
+
+	if goquery.NodeName(el) == "img" && strings.Contains(strings.ToLower(el.AttrOr("class", "")+el.AttrOr("src", "")), "flag") {
+		return providers.NormalizeLang(el.AttrOr("alt", ""))
+	}
+
+	return ""
+}
+
+func cleanText(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
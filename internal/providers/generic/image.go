@@ -1,6 +1,7 @@
 package generic
 
 import (
+	"fmt"
 	"net/url"
 	"path"
 	"regexp"
@@ -9,11 +10,10 @@ import (
 	"strings"
 
 	"github.com/PuerkitoBio/goquery"
+	"github.com/brogergvhs/mangad/internal/imgfmt"
 )
 
 var (
-	reExt = regexp.MustCompile(`(?i)\.(jpg|jpeg|png|webp)$`)
-
 	reSizeSuffix = regexp.MustCompile(`[-_]\d{2,5}x\d{2,5}`)
 	reParseSize  = regexp.MustCompile(`[-_](\d{2,5})x(\d{2,5})(?:\.[A-Za-z0-9]+)?$`)
 
@@ -33,6 +33,12 @@ type imageCollector struct {
 	items   []collectedItem
 	seen    map[string]bool
 	counter int
+
+	// stage and trace are only used by `mangad inspect`
+	stage   string
+	tracing bool
+	trace   []ImageCandidate
+	traced  map[string]int // URL -> position in trace of the kept entry
 }
 
 func newImageCollector(allowed *regexp.Regexp, debug bool) *imageCollector {
@@ -46,20 +52,30 @@ func newImageCollector(allowed *regexp.Regexp, debug bool) *imageCollector {
 }
 
 func (c *imageCollector) add(url string, idx int) {
-	if url == "" || strings.HasPrefix(url, "javascript:") {
+	if url == "" {
+		return
+	}
+	if strings.HasPrefix(url, "javascript:") {
+		c.note(url, idx, false, "javascript: URL")
 		return
 	}
 	lu := strings.ToLower(url)
 	if !c.allowed.MatchString(lu) {
+		c.note(url, idx, false, "extension not in allow_ext")
 		return
 	}
 	if strings.HasPrefix(lu, "data:") {
+		c.note(url, idx, false, "data: URI")
 		return
 	}
 	if strings.Contains(lu, "logo") {
+		c.note(url, idx, false, "contains \"logo\"")
 		return
 	}
 	if c.seen[url] {
+		if c.tracing {
+			c.note(url, idx, false, "duplicate, first found by "+c.trace[c.traced[url]].Stage)
+		}
 		return
 	}
 	c.seen[url] = true
@@ -69,26 +85,56 @@ func (c *imageCollector) add(url string, idx int) {
 		Index: idx,       // -1 if not known
 		Order: c.counter, // discovery sequence
 	})
+	c.note(url, idx, true, "")
 }
 
-func normalizeExtList(list []string) []string {
-	out := []string{}
-	for _, ext := range list {
-		ext = strings.ToLower(strings.TrimSpace(ext))
-		ext = strings.TrimPrefix(ext, ".")
-		if ext != "" {
-			out = append(out, ext)
-		}
+// enter sets the scan stage reported by inspect. Nested scans (e.g. HTML
+// embedded in Nuxt JSON) keep the outer stage.
+func (c *imageCollector) enter(stage string) func() {
+	if c.stage != "" {
+		return func() {}
 	}
 
-	return out
+	c.stage = stage
+	return func() { c.stage = "" }
+}
+
+func (c *imageCollector) note(url string, idx int, kept bool, reason string) {
+	if !c.tracing {
+		return
+	}
+
+	if kept {
+		c.traced[url] = len(c.trace)
+	}
+	c.trace = append(c.trace, ImageCandidate{
+		URL:    url,
+		Stage:  c.stage,
+		Index:  idx,
+		Kept:   kept,
+		Reason: reason,
+	})
 }
 
-func buildExtRegex(exts []string) *regexp.Regexp {
+// markDropped records a candidate removed after collection (filtering).
+func (c *imageCollector) markDropped(url, reason string) {
+	if i, ok := c.traced[url]; ok && c.tracing {
+		c.trace[i].Kept = false
+		c.trace[i].Reason = reason
+	}
+}
+
+// buildExtRegex matches URLs whose path ends in one of the allowed
+// extensions; a query string or fragment may follow.
+func buildExtRegex(formats imgfmt.Set) *regexp.Regexp {
+	exts := formats.Extensions()
 	if len(exts) == 0 {
 		return regexp.MustCompile(`$a`)
 	}
-	pattern := `(?i)\.(` + strings.Join(exts, "|") + `)$`
+	for i, e := range exts {
+		exts[i] = regexp.QuoteMeta(e)
+	}
+	pattern := `(?i)\.(` + strings.Join(exts, "|") + `)(?:[?#].*)?$`
 
 	return regexp.MustCompile(pattern)
 }
@@ -161,6 +207,8 @@ func getIndexFor(sel *goquery.Selection) int {
 }
 
 func (c *imageCollector) ScanIMGTags(doc *goquery.Document, chapterURL string) int {
+	defer c.enter("ScanIMGTags")()
+
 	before := len(c.items)
 	doc.Find("img").Each(func(_ int, img *goquery.Selection) {
 		idx := getIndexFor(img)
@@ -192,6 +240,8 @@ func (c *imageCollector) ScanIMGTags(doc *goquery.Document, chapterURL string) i
 }
 
 func (c *imageCollector) ScanBackgroundImages(doc *goquery.Document, chapterURL string) int {
+	defer c.enter("ScanBackgroundImages")()
+
 	before := len(c.items)
 	doc.Find("[style]").Each(func(_ int, el *goquery.Selection) {
 		style, _ := el.Attr("style")
@@ -212,6 +262,8 @@ func (c *imageCollector) ScanBackgroundImages(doc *goquery.Document, chapterURL
 }
 
 func (c *imageCollector) ScanAnchorImages(doc *goquery.Document, chapterURL string) int {
+	defer c.enter("ScanAnchorImages")()
+
 	before := len(c.items)
 	doc.Find("a[href]").Each(func(_ int, a *goquery.Selection) {
 		href, ok := a.Attr("href")
@@ -235,6 +287,8 @@ func (c *imageCollector) ScanAnchorImages(doc *goquery.Document, chapterURL stri
 }
 
 func (c *imageCollector) ScanPictureSources(doc *goquery.Document, chapterURL string) int {
+	defer c.enter("ScanPictureSources")()
+
 	before := len(c.items)
 
 	doc.Find("source[srcset]").Each(func(_ int, src *goquery.Selection) {
@@ -261,6 +315,8 @@ func (c *imageCollector) ScanPictureSources(doc *goquery.Document, chapterURL st
 }
 
 func (c *imageCollector) ScanNuxt(root map[string]any, chapterURL string) {
+	defer c.enter("ScanNuxt")()
+
 	var walk func(v any)
 
 	walk = func(v any) {
@@ -269,7 +325,7 @@ func (c *imageCollector) ScanNuxt(root map[string]any, chapterURL string) {
 			s := strings.TrimSpace(t)
 			ls := strings.ToLower(s)
 			if strings.HasPrefix(ls, "http://") || strings.HasPrefix(ls, "https://") {
-				if reExt.MatchString(ls) {
+				if c.allowed.MatchString(ls) {
 					c.add(s, -1)
 				}
 
@@ -297,11 +353,53 @@ func (c *imageCollector) ScanNuxt(root map[string]any, chapterURL string) {
 	walk(root)
 }
 
+// ScanScripts parses inline scripts for image arrays, object literals,
+// concatenations and JSON.parse payloads. The longest list is taken to be
+// the page list and gets page indices; other lists keep discovery order.
+func (c *imageCollector) ScanScripts(doc *goquery.Document, chapterURL string) int {
+	defer c.enter("ScanScripts")()
+
+	var js strings.Builder
+	doc.Find("script").Each(func(_ int, sc *goquery.Selection) {
+		if _, ok := sc.Attr("src"); ok {
+			return
+		}
+		js.WriteString(sc.Text())
+		js.WriteString("\n;\n")
+	})
+
+	lists := ExtractJSImages(js.String())
+	if len(lists) == 0 {
+		return 0
+	}
+
+	pages := 0
+	for i, l := range lists {
+		if len(l) > len(lists[pages]) {
+			pages = i
+		}
+	}
+
+	before := len(c.items)
+	for i, l := range lists {
+		for j, u := range l {
+			idx := -1
+			if i == pages && len(l) > 1 {
+				idx = j
+			}
+			c.add(resolve(chapterURL, u), idx)
+		}
+	}
+
+	return len(c.items) - before
+}
+
 func (c *imageCollector) ScanLooseURLs(body string) {
 	if body == "" {
 		return
 	}
 
+	defer c.enter("ScanLooseURLs")()
 	for _, u := range reLooseURLs.FindAllString(body, -1) {
 		c.add(u, -1)
 	}
@@ -315,6 +413,7 @@ func (c *imageCollector) Finalize() []string {
 	groups := groupCollectedItems(c.items)
 	chosenList := chooseBestImages(groups)
 	sortChosen(chosenList)
+	c.traceGroups(groups, chosenList)
 
 	out := make([]string, len(chosenList))
 	for i := range chosenList {
@@ -324,6 +423,38 @@ func (c *imageCollector) Finalize() []string {
 	return out
 }
 
+func (c *imageCollector) traceGroups(groups map[string][]collectedItem, chosen []chosenItem) {
+	if !c.tracing {
+		return
+	}
+
+	picked := make(map[string]int, len(chosen))
+	for i, ch := range chosen {
+		picked[ch.URL] = i
+	}
+
+	for key, items := range groups {
+		var best string
+		for _, it := range items {
+			if _, ok := picked[it.URL]; ok {
+				best = it.URL
+			}
+		}
+
+		for _, it := range items {
+			t := &c.trace[c.traced[it.URL]]
+			t.Group = key
+			if it.URL == best {
+				t.Reason = fmt.Sprintf("best of %d in group", len(items))
+				t.Index = chosen[picked[best]].Index
+				continue
+			}
+			t.Kept = false
+			t.Reason = "lower-quality variant of " + best
+		}
+	}
+}
+
 // groupCollectedItems groups collected images by their normalized base URL.
 func groupCollectedItems(items []collectedItem) map[string][]collectedItem {
 	type grp struct {
@@ -0,0 +1,77 @@
+package generic
+
+import (
+	"context"
+
+	"github.com/brogergvhs/mangad/internal/providers"
+)
+
+// ImageCandidate is one image URL seen while scanning a chapter page.
+type ImageCandidate struct {
+	URL    string `json:"url"`
+	Stage  string `json:"stage"`           // scan that found it, e.g. ScanIMGTags
+	Index  int    `json:"index"`           // data-index or script position, -1 if none
+	Group  string `json:"group,omitempty"` // normalized base URL used for grouping
+	Kept   bool   `json:"kept"`
+	Page   int    `json:"page,omitempty"` // 1-based page number when kept
+	Reason string `json:"reason,omitempty"`
+}
+
+// ChapterLink is one anchor seen while scanning a series page.
+type ChapterLink struct {
+	Href     string `json:"href"`
+	Text     string `json:"text"`
+	Accepted bool   `json:"accepted"`
+	Label    string `json:"label,omitempty"`
+	Reason   string `json:"reason"`
+	// Container is the container path and URL directory the link was
+	// clustered under.
+	Container string `json:"container,omitempty"`
+}
+
+// Report explains the extraction decisions taken for one page.
+type Report struct {
+	URL        string              `json:"url"`
+	Container  string              `json:"container,omitempty"` // selected chapter list container
+	Chapters   []providers.Chapter `json:"chapters"`
+	Links      []ChapterLink       `json:"links"`
+	Images     []string            `json:"images"`
+	Candidates []ImageCandidate    `json:"candidates"`
+	ImageError string              `json:"image_error,omitempty"`
+}
+
+// Inspect runs both the chapter and the image extraction on pageURL and
+// records why every anchor and image candidate was accepted or dropped.
+// It works for series and chapter pages alike.
+func (s *Scraper) Inspect(ctx context.Context, pageURL string) (*Report, error) {
+	doc, err := s.fetchDOM(ctx, pageURL)
+	if err != nil {
+		return nil, err
+	}
+
+	rep := &Report{URL: pageURL}
+	rep.Chapters = s.collectChapters(doc, pageURL, rep)
+
+	col := newImageCollector(s.allowed, s.log.DebugEnabled())
+	col.tracing = true
+	col.traced = map[string]int{}
+
+	images, err := s.collectImages(ctx, pageURL, col)
+	if err != nil {
+		rep.ImageError = err.Error()
+	}
+	rep.Images = images
+
+	pages := make(map[string]int, len(images))
+	for i, u := range images {
+		pages[u] = i + 1
+	}
+	for i := range col.trace {
+		if c := &col.trace[i]; c.Kept {
+			c.Page = pages[c.URL]
+		}
+	}
+	rep.Candidates = col.trace
+
+	return rep, nil
+}
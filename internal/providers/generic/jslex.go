@@ -0,0 +1,517 @@
+package generic
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// A small JavaScript tokenizer and literal parser. It doesn't evaluate
+// anything: it only understands enough syntax to find array/object
+// literals, string concatenations of known string variables, and
+// JSON.parse('...') payloads that carry page image URLs.
+
+type jsTokKind int
+
+const (
+	tokString jsTokKind = iota
+	tokNumber
+	tokIdent
+	tokPunct
+	tokTemplate // template literal with ${} substitutions, Value is raw
+)
+
+type jsToken struct {
+	Kind  jsTokKind
+	Value string
+}
+
+const maxJSDepth = 64
+
+var reJSImage = regexp.MustCompile(`(?i)\.(?:jpe?g|png|webp|gif|avif|jxl|bmp)(?:[?#].*)?$`)
+
+// regexAfter lists tokens after which a '/' starts a regex literal rather
+// than a division.
+var regexAfter = map[string]bool{
+	"(": true, ",": true, "=": true, ":": true, "[": true, "!": true, "&": true,
+	"|": true, "?": true, "{": true, "}": true, ";": true, "+": true, "-": true,
+	"*": true, "%": true, "<": true, ">": true, "~": true, "^": true,
+	"return": true, "typeof": true, "case": true, "do": true, "else": true,
+	"in": true, "of": true, "void": true, "delete": true, "throw": true,
+}
+
+func tokenizeJS(src string) []jsToken {
+	var toks []jsToken
+	i, n := 0, len(src)
+
+	lastSignificant := func() string {
+		if len(toks) == 0 {
+			return "("
+		}
+		t := toks[len(toks)-1]
+		if t.Kind == tokPunct || t.Kind == tokIdent {
+			return t.Value
+		}
+		return ""
+	}
+
+	for i < n {
+		c := src[i]
+
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v':
+			i++
+
+		case c == '/' && i+1 < n && src[i+1] == '/':
+			for i < n && src[i] != '\n' {
+				i++
+			}
+
+		case c == '/' && i+1 < n && src[i+1] == '*':
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				i = n
+			} else {
+				i += end + 4
+			}
+
+		case c == '/' && regexAfter[lastSignificant()]:
+			i = skipRegex(src, i)
+
+		case c == '"' || c == '\'':
+			s, next := readJSString(src, i)
+			toks = append(toks, jsToken{Kind: tokString, Value: s})
+			i = next
+
+		case c == '`':
+			raw, next := readTemplate(src, i)
+			if strings.Contains(raw, "${") {
+				toks = append(toks, jsToken{Kind: tokTemplate, Value: raw})
+			} else {
+				toks = append(toks, jsToken{Kind: tokString, Value: unescapeJS(raw)})
+			}
+			i = next
+
+		case c >= '0' && c <= '9' || c == '.' && i+1 < n && src[i+1] >= '0' && src[i+1] <= '9':
+			start := i
+			for i < n && (isIdentByte(src[i]) || src[i] == '.') {
+				i++
+			}
+			toks = append(toks, jsToken{Kind: tokNumber, Value: src[start:i]})
+
+		case isIdentByte(c) || c >= utf8.RuneSelf:
+			start := i
+			for i < n && (isIdentByte(src[i]) || src[i] >= utf8.RuneSelf) {
+				i++
+			}
+			toks = append(toks, jsToken{Kind: tokIdent, Value: src[start:i]})
+
+		default:
+			toks = append(toks, jsToken{Kind: tokPunct, Value: string(c)})
+			i++
+		}
+	}
+
+	return toks
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c == '$' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+func skipRegex(src string, i int) int {
+	n := len(src)
+	inClass := false
+	for i++; i < n; i++ {
+		switch src[i] {
+		case '\\':
+			i++
+		case '[':
+			inClass = true
+		case ']':
+			inClass = false
+		case '\n':
+			return i
+		case '/':
+			if !inClass {
+				i++
+				for i < n && isIdentByte(src[i]) {
+					i++
+				}
+				return i
+			}
+		}
+	}
+
+	return n
+}
+
+func readJSString(src string, i int) (string, int) {
+	quote := src[i]
+	n := len(src)
+	start := i + 1
+
+	for i = start; i < n; i++ {
+		switch src[i] {
+		case '\\':
+			i++
+		case quote:
+			return unescapeJS(src[start:i]), i + 1
+		case '\n':
+			return unescapeJS(src[start:i]), i
+		}
+	}
+
+	return unescapeJS(src[start:]), n
+}
+
+func readTemplate(src string, i int) (string, int) {
+	n := len(src)
+	start := i + 1
+
+	for i = start; i < n; i++ {
+		switch src[i] {
+		case '\\':
+			i++
+		case '`':
+			return src[start:i], i + 1
+		}
+	}
+
+	return src[start:], n
+}
+
+// unescapeJS decodes JS string escapes (\n, \xHH, \uHHHH, \u{...}, \/, ...).
+func unescapeJS(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+
+	var b strings.Builder
+	b.Grow(len(s))
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '\\' || i+1 >= len(s) {
+			b.WriteByte(c)
+			continue
+		}
+
+		i++
+		switch e := s[i]; e {
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case 'r':
+			b.WriteByte('\r')
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'v':
+			b.WriteByte('\v')
+		case '0':
+			b.WriteByte(0)
+		case '\n':
+			// line continuation
+		case 'x':
+			if i+2 < len(s) {
+				if v, err := strconv.ParseUint(s[i+1:i+3], 16, 8); err == nil {
+					b.WriteRune(rune(v))
+					i += 2
+					continue
+				}
+			}
+			b.WriteByte(e)
+		case 'u':
+			if i+1 < len(s) && s[i+1] == '{' {
+				if end := strings.IndexByte(s[i:], '}'); end > 0 {
+					if v, err := strconv.ParseUint(s[i+2:i+end], 16, 32); err == nil {
+						b.WriteRune(rune(v))
+						i += end
+						continue
+					}
+				}
+			} else if i+4 < len(s) {
+				if v, err := strconv.ParseUint(s[i+1:i+5], 16, 16); err == nil {
+					b.WriteRune(rune(v))
+					i += 4
+					continue
+				}
+			}
+			b.WriteByte(e)
+		default:
+			b.WriteByte(e)
+		}
+	}
+
+	return b.String()
+}
+
+// jsValue is a parsed literal: a string, an array or an object. All fields
+// are empty for expressions we don't understand.
+type jsValue struct {
+	Str    *string
+	List   []jsValue
+	Fields []jsValue // object values in source order
+	IsList bool
+	IsObj  bool
+}
+
+type jsParser struct {
+	toks  []jsToken
+	vars  map[string]string
+	depth int
+}
+
+func (p *jsParser) tok(i int) (jsToken, bool) {
+	if i < 0 || i >= len(p.toks) {
+		return jsToken{}, false
+	}
+
+	return p.toks[i], true
+}
+
+func (p *jsParser) isPunct(i int, v string) bool {
+	t, ok := p.tok(i)
+	return ok && t.Kind == tokPunct && t.Value == v
+}
+
+// parseValue parses the literal starting at token i and returns it along
+// with the index of the first token after it.
+func (p *jsParser) parseValue(i int) (jsValue, int) {
+	t, ok := p.tok(i)
+	if !ok {
+		return jsValue{}, i + 1
+	}
+	if p.depth > maxJSDepth {
+		return jsValue{}, i + 1
+	}
+
+	p.depth++
+	defer func() { p.depth-- }()
+
+	switch {
+	case t.Kind == tokPunct && t.Value == "[":
+		return p.parseArray(i + 1)
+
+	case t.Kind == tokPunct && t.Value == "{":
+		return p.parseObject(i + 1)
+
+	case t.Kind == tokString:
+		return p.parseConcat(t.Value, i+1)
+
+	case t.Kind == tokIdent && t.Value == "JSON" && p.isPunct(i+1, ".") &&
+		p.isIdent(i+2, "parse") && p.isPunct(i+3, "("):
+		if arg, ok := p.tok(i + 4); ok && arg.Kind == tokString {
+			sub := &jsParser{toks: tokenizeJS(arg.Value), vars: p.vars, depth: p.depth}
+			v, _ := sub.parseValue(0)
+			next := i + 5
+			if p.isPunct(next, ")") {
+				next++
+			}
+			return v, next
+		}
+		return jsValue{}, i + 4
+
+	case t.Kind == tokIdent:
+		if s, ok := p.vars[t.Value]; ok {
+			return p.parseConcat(s, i+1)
+		}
+	}
+
+	return jsValue{}, i + 1
+}
+
+func (p *jsParser) isIdent(i int, v string) bool {
+	t, ok := p.tok(i)
+	return ok && t.Kind == tokIdent && t.Value == v
+}
+
+// parseConcat folds `"a" + "b" + knownVar` into one string.
+func (p *jsParser) parseConcat(s string, i int) (jsValue, int) {
+	for p.isPunct(i, "+") {
+		t, ok := p.tok(i + 1)
+		if !ok {
+			break
+		}
+		if t.Kind == tokString {
+			s += t.Value
+		} else if v, known := p.vars[t.Value]; t.Kind == tokIdent && known {
+			s += v
+		} else {
+			break
+		}
+		i += 2
+	}
+
+	return jsValue{Str: &s}, i
+}
+
+func (p *jsParser) parseArray(i int) (jsValue, int) {
+	v := jsValue{IsList: true}
+	for i < len(p.toks) {
+		switch {
+		case p.isPunct(i, "]"):
+			return v, i + 1
+		case p.isPunct(i, ","):
+			i++
+		default:
+			el, next := p.parseValue(i)
+			if el.Str != nil || el.IsList || el.IsObj {
+				v.List = append(v.List, el)
+			}
+			i = next
+		}
+	}
+
+	return v, i
+}
+
+func (p *jsParser) parseObject(i int) (jsValue, int) {
+	v := jsValue{IsObj: true}
+	for i < len(p.toks) {
+		switch {
+		case p.isPunct(i, "}"):
+			return v, i + 1
+		case p.isPunct(i, ":"):
+			el, next := p.parseValue(i + 1)
+			if el.Str != nil || el.IsList || el.IsObj {
+				v.Fields = append(v.Fields, el)
+			}
+			i = next
+		case p.isPunct(i, "{"):
+			_, next := p.parseObject(i + 1)
+			i = next
+		default:
+			i++
+		}
+	}
+
+	return v, i
+}
+
+// ExtractJSImages returns the lists of image URLs found in literal
+// structures of the given scripts, in source order. Each list keeps the
+// order of its literal (e.g. the elements of `var chapImages = [...]`).
+func ExtractJSImages(js string) [][]string {
+	p := &jsParser{toks: tokenizeJS(js), vars: map[string]string{}}
+
+	var lists [][]string
+	for i := 0; i < len(p.toks); {
+		t := p.toks[i]
+
+		if t.Kind == tokIdent && p.isPunct(i+1, "=") && !p.isPunct(i+2, "=") {
+			v, next := p.parseValue(i + 2)
+			if v.Str != nil {
+				p.vars[t.Value] = *v.Str
+			}
+			lists = append(lists, imageLists(v)...)
+			i = max(next, i+2)
+			continue
+		}
+
+		startsLiteral := t.Kind == tokString ||
+			t.Kind == tokPunct && t.Value == "[" ||
+			t.Kind == tokIdent && t.Value == "JSON" ||
+			t.Kind == tokPunct && t.Value == "{" && i > 0 && objectContext(p.toks[i-1])
+
+		if !startsLiteral {
+			i++
+			continue
+		}
+
+		v, next := p.parseValue(i)
+		lists = append(lists, imageLists(v)...)
+		i = max(next, i+1)
+	}
+
+	return lists
+}
+
+// objectContext reports whether a '{' after prev is an object literal
+// rather than a code block.
+func objectContext(prev jsToken) bool {
+	if prev.Kind == tokIdent {
+		return prev.Value == "return"
+	}
+
+	return prev.Kind == tokPunct && strings.Contains("=:(,[", prev.Value)
+}
+
+// imageLists flattens a parsed literal into ordered lists of image URLs.
+func imageLists(v jsValue) [][]string {
+	var out [][]string
+
+	switch {
+	case v.Str != nil:
+		if urls := splitImageRefs(*v.Str); len(urls) > 0 {
+			out = append(out, urls)
+		}
+
+	case v.IsList || v.IsObj:
+		elems := v.List
+		if v.IsObj {
+			elems = v.Fields
+		}
+
+		var direct []string
+		for _, el := range elems {
+			if u := firstImageRef(el); u != "" {
+				direct = append(direct, u)
+				continue
+			}
+			out = append(out, imageLists(el)...)
+		}
+		if len(direct) > 0 {
+			out = append([][]string{direct}, out...)
+		}
+	}
+
+	return out
+}
+
+// firstImageRef returns the image URL an element stands for: the string
+// itself, or for an object like {src: "...", w: 800} its first image field.
+func firstImageRef(v jsValue) string {
+	if v.Str != nil {
+		if isImageRef(*v.Str) {
+			return strings.TrimSpace(*v.Str)
+		}
+		return ""
+	}
+
+	if v.IsObj {
+		for _, f := range v.Fields {
+			if f.Str != nil && isImageRef(*f.Str) {
+				return strings.TrimSpace(*f.Str)
+			}
+		}
+	}
+
+	return ""
+}
+
+// splitImageRefs handles a single URL as well as comma-joined URL strings.
+func splitImageRefs(s string) []string {
+	var out []string
+	for part := range strings.SplitSeq(s, ",") {
+		part = strings.TrimSpace(part)
+		if isImageRef(part) {
+			out = append(out, part)
+		}
+	}
+
+	return out
+}
+
+func isImageRef(s string) bool {
+	s = strings.TrimSpace(s)
+	if s == "" || strings.ContainsAny(s, " \t\n<>\"'") || !strings.Contains(s, "/") {
+		return false
+	}
+
+	return reJSImage.MatchString(s)
+}
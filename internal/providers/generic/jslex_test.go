@@ -0,0 +1,199 @@
+package generic
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokenizeJS(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		src  string
+		want []jsToken
+	}{
+		{
+			name: "assignment",
+			src:  `var pages = ["a.jpg", 'b.jpg'];`,
+			want: []jsToken{
+				{tokIdent, "var"}, {tokIdent, "pages"}, {tokPunct, "="}, {tokPunct, "["},
+				{tokString, "a.jpg"}, {tokPunct, ","}, {tokString, "b.jpg"}, {tokPunct, "]"}, {tokPunct, ";"},
+			},
+		},
+		{
+			name: "comments are skipped",
+			src:  "a = 1; // \"not a string\"\n/* 'nor this' */ b",
+			want: []jsToken{
+				{tokIdent, "a"}, {tokPunct, "="}, {tokNumber, "1"}, {tokPunct, ";"}, {tokIdent, "b"},
+			},
+		},
+		{
+			name: "regex literal after =",
+			src:  `re = /"[^"]+"/g; s = "x"`,
+			want: []jsToken{
+				{tokIdent, "re"}, {tokPunct, "="}, {tokPunct, ";"},
+				{tokIdent, "s"}, {tokPunct, "="}, {tokString, "x"},
+			},
+		},
+		{
+			name: "regex with a slash in a class",
+			src:  `m = s.match(/[/"]x/); t = "y"`,
+			want: []jsToken{
+				{tokIdent, "m"}, {tokPunct, "="}, {tokIdent, "s"}, {tokPunct, "."}, {tokIdent, "match"},
+				{tokPunct, "("}, {tokPunct, ")"}, {tokPunct, ";"},
+				{tokIdent, "t"}, {tokPunct, "="}, {tokString, "y"},
+			},
+		},
+		{
+			name: "division after a value",
+			src:  `w = total / 2 / "3"`,
+			want: []jsToken{
+				{tokIdent, "w"}, {tokPunct, "="}, {tokIdent, "total"}, {tokPunct, "/"},
+				{tokNumber, "2"}, {tokPunct, "/"}, {tokString, "3"},
+			},
+		},
+		{
+			name: "division after a closing paren",
+			src:  `h = (a + b) / 2`,
+			want: []jsToken{
+				{tokIdent, "h"}, {tokPunct, "="}, {tokPunct, "("}, {tokIdent, "a"}, {tokPunct, "+"},
+				{tokIdent, "b"}, {tokPunct, ")"}, {tokPunct, "/"}, {tokNumber, "2"},
+			},
+		},
+		{
+			name: "template literals",
+			src:  "a = `/img/1.jpg`; b = `${base}/2.jpg`",
+			want: []jsToken{
+				{tokIdent, "a"}, {tokPunct, "="}, {tokString, "/img/1.jpg"}, {tokPunct, ";"},
+				{tokIdent, "b"}, {tokPunct, "="}, {tokTemplate, "${base}/2.jpg"},
+			},
+		},
+		{
+			name: "escaped quote inside a string",
+			src:  `s = "say \"hi\"", t`,
+			want: []jsToken{
+				{tokIdent, "s"}, {tokPunct, "="}, {tokString, `say "hi"`}, {tokPunct, ","}, {tokIdent, "t"},
+			},
+		},
+		{
+			name: "unterminated string stops at the line end",
+			src:  "s = 'abc\nt = 1",
+			want: []jsToken{
+				{tokIdent, "s"}, {tokPunct, "="}, {tokString, "abc"},
+				{tokIdent, "t"}, {tokPunct, "="}, {tokNumber, "1"},
+			},
+		},
+		{
+			name: "numbers",
+			src:  `x = [.5, 1e3, 0x1f]`,
+			want: []jsToken{
+				{tokIdent, "x"}, {tokPunct, "="}, {tokPunct, "["}, {tokNumber, ".5"}, {tokPunct, ","},
+				{tokNumber, "1e3"}, {tokPunct, ","}, {tokNumber, "0x1f"}, {tokPunct, "]"},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tokenizeJS(tc.src); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("tokenizeJS(%q)\n got %v\nwant %v", tc.src, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestUnescapeJS(t *testing.T) {
+	for _, tc := range []struct{ in, want string }{
+		{`plain`, "plain"},
+		{`https:\/\/cdn.example.com\/1.jpg`, "https://cdn.example.com/1.jpg"},
+		{`a\nb\tc\rd`, "a\nb\tc\rd"},
+		{`\x41\x2F`, "A/"},
+		{`Aé`, "Aé"},
+		{`\u{1F600}`, "😀"},
+		{`\'\"\\`, `'"\`},
+		{"line\\\ncontinued", "linecontinued"},
+		{`\q`, "q"},
+		{`\xZZ`, "xZZ"},
+		{`\u12`, "u12"},
+		{`trailing\`, `trailing\`},
+	} {
+		if got := unescapeJS(tc.in); got != tc.want {
+			t.Errorf("unescapeJS(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestExtractJSImages(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		js   string
+		want [][]string
+	}{
+		{
+			name: "array variable",
+			js:   `var chapImages = ["https://cdn.example.com/1.jpg", "https://cdn.example.com/2.jpg"];`,
+			want: [][]string{{"https://cdn.example.com/1.jpg", "https://cdn.example.com/2.jpg"}},
+		},
+		{
+			name: "escaped slashes",
+			js:   `var pages = ["https:\/\/cdn.example.com\/p\/001.webp","https:\/\/cdn.example.com\/p\/002.webp"];`,
+			want: [][]string{{"https://cdn.example.com/p/001.webp", "https://cdn.example.com/p/002.webp"}},
+		},
+		{
+			name: "comma-joined string",
+			js:   `var chapter_images = "/uploads/1.png,/uploads/2.png, /uploads/3.png";`,
+			want: [][]string{{"/uploads/1.png", "/uploads/2.png", "/uploads/3.png"}},
+		},
+		{
+			name: "concatenation with a known base",
+			js: `var base = "https://img.example.com/ch12/";
+				var imgs = [base + "01.jpg", base + "02.jpg"];`,
+			want: [][]string{{"https://img.example.com/ch12/01.jpg", "https://img.example.com/ch12/02.jpg"}},
+		},
+		{
+			name: "objects with a src field",
+			js:   `window.__DATA__ = {pages: [{src: "/p/1.jpg", w: 800}, {src: "/p/2.jpg", w: 800}], title: "Ch. 1"};`,
+			want: [][]string{{"/p/1.jpg", "/p/2.jpg"}},
+		},
+		{
+			name: "JSON.parse payload",
+			js:   `const data = JSON.parse('{"images":["/a/1.jpg","/a/2.jpg"]}');`,
+			want: [][]string{{"/a/1.jpg", "/a/2.jpg"}},
+		},
+		{
+			name: "ts_reader style call",
+			js:   `ts_reader.run({"sources":[{"source":"Server 1","images":["https://x.example/1.jpg","https://x.example/2.jpg"]}]});`,
+			want: [][]string{{"https://x.example/1.jpg", "https://x.example/2.jpg"}},
+		},
+		{
+			name: "regex containing quotes doesn't swallow the list",
+			js: `var re = /"(.*?)"/g, ok = true;
+				var pages = ["/img/1.jpg", "/img/2.jpg"];`,
+			want: [][]string{{"/img/1.jpg", "/img/2.jpg"}},
+		},
+		{
+			name: "division isn't read as a regex",
+			js: `var ratio = width / 2; var s = "/img/cover.jpg";
+				var n = height / 3;`,
+			want: [][]string{{"/img/cover.jpg"}},
+		},
+		{
+			name: "template with substitution is skipped",
+			js:   "var a = `${cdn}/1.jpg`; var b = `/static/2.jpg`;",
+			want: [][]string{{"/static/2.jpg"}},
+		},
+		{
+			name: "comparison is not an assignment",
+			js:   `if (page == "/x/1.jpg") {}`,
+			want: [][]string{{"/x/1.jpg"}},
+		},
+		{
+			name: "non-image strings are ignored",
+			js:   `var links = ["/manga/one-piece/", "https://example.com/chapter-2"];`,
+			want: nil,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := ExtractJSImages(tc.js); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("ExtractJSImages\n got %q\nwant %q", got, tc.want)
+			}
+		})
+	}
+}
@@ -1,30 +1,30 @@
 package generic
 
 import (
-	"bytes"
 	"context"
-	_ "embed"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
-	"os"
-	"os/exec"
+	"path"
 	"regexp"
-	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/PuerkitoBio/goquery"
+	"github.com/brogergvhs/mangad/internal/fetcher"
+	"github.com/brogergvhs/mangad/internal/imgfmt"
 	"github.com/brogergvhs/mangad/internal/providers"
 	"github.com/brogergvhs/mangad/internal/ui"
 	"github.com/brogergvhs/mangad/internal/util"
 )
 
-//go:embed selenium_fetch.py
-var embeddedSeleniumScript []byte
+// ErrCloudflareBlocked is returned when a page answers with a Cloudflare
+// challenge and the browser fallback is not allowed.
+var ErrCloudflareBlocked = errors.New("cloudflare challenge blocked")
 
 type Scraper struct {
 	client  *http.Client
@@ -32,16 +32,47 @@ type Scraper struct {
 	allowed *regexp.Regexp
 	checkJS bool
 	withCF  bool
+	block   []*regexp.Regexp
+	probe   bool
+	fetcher fetcher.Fetcher
+	fetch   FetchOptions
 }
 
-func NewScraper(c *http.Client, log *ui.Logger, allowExt []string, checkJS bool, withCF bool) *Scraper {
+type ScraperOptions struct {
+	AllowExt      []string
+	CheckJS       bool
+	WithCF        bool
+	BlockPatterns []string // extra regexes for image URLs that are never pages
+	ProbeImages   bool     // probe image headers to drop tiny icons and banners
+	Fetcher       fetcher.Fetcher
+	Fetch         FetchOptions
+}
+
+// FetchOptions are passed to the external fetcher with every request.
+type FetchOptions struct {
+	UserAgent    string // only forwarded when explicitly configured
+	Proxy        string
+	WaitSelector string
+	WaitSeconds  float64
+}
+
+func NewScraper(c *http.Client, log *ui.Logger, opts ScraperOptions) (*Scraper, error) {
+	block, err := compileBlockPatterns(opts.BlockPatterns)
+	if err != nil {
+		return nil, err
+	}
+
 	return &Scraper{
 		client:  c,
 		log:     log,
-		allowed: buildExtRegex(normalizeExtList(allowExt)),
-		checkJS: checkJS,
-		withCF:  withCF,
-	}
+		allowed: buildExtRegex(imgfmt.NewSet(opts.AllowExt)),
+		checkJS: opts.CheckJS,
+		withCF:  opts.WithCF,
+		block:   block,
+		probe:   opts.ProbeImages,
+		fetcher: opts.Fetcher,
+		fetch:   opts.Fetch,
+	}, nil
 }
 
 var (
@@ -50,9 +81,11 @@ var (
 
 	batoSimple  = regexp.MustCompile(`(?:^|[/\-_])ch[_\-]?(\d+(?:\.\d+)?)`)
 	batoVol     = regexp.MustCompile(`vol[_\-]?(\d+)[/_\-]ch[_\-]?(\d+(?:\.\d+)?)`)
+	hrefVolume  = regexp.MustCompile(`(?:^|[/\-_])vol(?:ume)?[_\-]?0*(\d+)(?:$|[/\-_])`)
 	batoPlain   = regexp.MustCompile(`[/\-](\d+(?:\.\d+)?)(?:$|[/\-_])`)
 	titlePrefix = regexp.MustCompile(`^\s*(\d+(?:\.\d+)?)\s*[.\- ]`)
 
+	reLeadingVolume = regexp.MustCompile(`^v(?:ol(?:ume)?)?\.?\s*\d+\s*[:\-]?\s*`)
 	reLikelyChapter = regexp.MustCompile(`(?i)(?:^|[-_/])(?:ch|chapter)[-_]?\d+`)
 	reNuxt          = regexp.MustCompile(`window\.__NUXT__\s*=\s*(\{.*?});`)
 )
@@ -68,6 +101,12 @@ func (s *Scraper) fetchDOM(ctx context.Context, target string) (*goquery.Documen
 	return goquery.NewDocumentFromReader(strings.NewReader(body))
 }
 
+// Fetch returns the body of target, going through the browser fallback
+// when it is allowed and the site answers with a challenge.
+func (s *Scraper) Fetch(ctx context.Context, target string) (string, error) {
+	return s.fetchBody(ctx, target)
+}
+
 func (s *Scraper) fetchBody(ctx context.Context, target string) (string, error) {
 	s.log.Debugf("Fetching body for URL: %s\n", target)
 
@@ -97,82 +136,134 @@ func (s *Scraper) fetchBody(ctx context.Context, target string) (string, error)
 
 	if resp.StatusCode == http.StatusForbidden || strings.Contains(body, "Just a moment") {
 		if !s.withCF {
-			s.log.Infof("Cloudflare protection detected for %s.\n", target)
-			s.log.Infof("Selenium fallback disabled. Re-run with --with-cf or enable with_cf in config to allow bypass.\n")
-			return "", fmt.Errorf("cloudflare challenge blocked (use --with-cf to allow bypass)")
+			s.log.Warn("Cloudflare protection detected; browser fallback disabled (re-run with --with-cf or enable with_cf in config)", "url", target)
+			return "", fmt.Errorf("%w (use --with-cf to allow bypass)", ErrCloudflareBlocked)
 		}
 
-		tmpFile, err := os.CreateTemp("", "selenium_fetch_*.py")
-		if err != nil {
-			return "", fmt.Errorf("failed to create temp script: %w", err)
-		}
-		defer func() {
-			if err := os.Remove(tmpFile.Name()); err != nil {
-				s.log.Debugf("Warning: failed to remove temp file %s: %v\n", tmpFile.Name(), err)
-			}
-		}()
+		return s.fetchExternal(ctx, target)
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return "", &util.HTTPError{Status: resp.StatusCode, URL: target}
+	}
 
-		if _, err := tmpFile.Write(embeddedSeleniumScript); err != nil {
-			if cerr := tmpFile.Close(); cerr != nil {
-				s.log.Debugf("Warning: failed to close temp file: %v\n", cerr)
-			}
-			return "", fmt.Errorf("failed to write embedded selenium script: %w", err)
-		}
-		if err := tmpFile.Close(); err != nil {
-			s.log.Debugf("Warning: failed to close temp file: %v\n", err)
-		}
+	return body, nil
+}
 
-		s.log.Debugf("Running embedded Selenium script for %s\n", target)
-		cmd := exec.CommandContext(ctx, "python3", tmpFile.Name(), target)
+// chapterLabel is the parsed numbering of a chapter link.
+type chapterLabel struct {
+	NumMain    int
+	SuffixType string
+	SuffixNum  int
+	Label      string
+	Number     float64
+	Volume     int
+	Kind       providers.ChapterKind
+}
 
-		var out bytes.Buffer
-		var stderr bytes.Buffer
-		cmd.Stdout = &out
-		cmd.Stderr = &stderr
+// fetchExternal loads a page through the external fetcher (a headless
+// browser by default), forwarding the client's cookies and headers.
+func (s *Scraper) fetchExternal(ctx context.Context, target string) (string, error) {
+	f := s.fetcher
+	if f == nil {
+		f = fetcher.New(nil)
+	}
 
-		err = cmd.Run()
-		if err != nil {
-			s.log.Errorf("Selenium script failed: %v\nstderr: %s\n", err, stderr.String())
-			return "", err
-		}
+	req := fetcher.Request{
+		URL:     target,
+		Headers: map[string]string{},
+		Proxy:   s.fetch.Proxy,
+		Wait: fetcher.Wait{
+			Selector: s.fetch.WaitSelector,
+			Seconds:  s.fetch.WaitSeconds,
+		},
+	}
+	if s.fetch.UserAgent != "" {
+		req.Headers["User-Agent"] = s.fetch.UserAgent
+	}
+	if u, err := url.Parse(target); err == nil && s.client.Jar != nil {
+		req.Cookies = fetcher.FromHTTPCookies(s.client.Jar.Cookies(u), u.Hostname())
+	}
 
-		html := out.String()
-		s.log.Debugf("Fetched body via embedded Selenium for %s\n", target)
+	s.log.Debugf("Running external fetcher for %s\n", target)
 
-		return html, nil
+	resp, err := f.Fetch(ctx, req)
+	if err != nil {
+		s.log.Error("External fetcher failed", "url", target, "stage", "fetch", "err", err)
+		return "", err
 	}
 
-	return body, nil
+	s.log.Debugf("Fetched body via external fetcher for %s (final URL %s)\n", target, resp.URL)
+	s.adoptBrowserSession(resp)
+
+	return resp.HTML, nil
+}
+
+// adoptBrowserSession hands the browser's cookies (Cloudflare clearance in
+// particular) and User-Agent over to the HTTP client, so later page and
+// image requests to the same host pass without another browser run.
+func (s *Scraper) adoptBrowserSession(resp *fetcher.Response) {
+	u, err := url.Parse(resp.URL)
+	if err != nil || u.Host == "" {
+		return
+	}
+
+	if s.client.Jar != nil && len(resp.Cookies) > 0 {
+		s.client.Jar.SetCookies(u, fetcher.HTTPCookies(resp.Cookies))
+		s.log.Debugf("Adopted %d browser cookies for %s\n", len(resp.Cookies), u.Hostname())
+	}
+
+	if resp.UserAgent == "" {
+		return
+	}
+
+	host := u.Hostname()
+	for _, c := range resp.Cookies {
+		// bind the UA to the cookie domain so CDN subdomains share it
+		if d := strings.TrimPrefix(c.Domain, "."); d != "" && strings.HasSuffix(host, "."+d) {
+			host = d
+		}
+	}
+	if util.SetHostUserAgent(s.client, host, resp.UserAgent) {
+		s.log.Debugf("Using browser User-Agent for %s: %s\n", host, resp.UserAgent)
+	}
 }
 
-func parseChapterLabel(href, title string) (int, string, int, string, bool) {
+func parseChapterLabel(href, title string) (chapterLabel, bool) {
 	h := strings.ToLower(href)
 	t := strings.ToLower(title)
 
 	if !hasChapterKeywords(h, t) || isExcluded(h) {
-		return 0, "", 0, "", false
+		return chapterLabel{}, false
 	}
 
-	if n, typ, sn, label, ok := matchChapterDash(h); ok {
-		return n, typ, sn, label, true
-	}
-	if n, typ, sn, label, ok := matchBatoVol(h); ok {
-		return n, typ, sn, label, true
-	}
-	if n, typ, sn, label, ok := matchBatoSimple(h); ok {
-		return n, typ, sn, label, true
+	kind := detectKind(h, title)
+
+	matchers := []func() (chapterLabel, bool){
+		func() (chapterLabel, bool) { return matchChapterDash(h) },
+		func() (chapterLabel, bool) { return matchBatoVol(h) },
+		func() (chapterLabel, bool) { return matchBatoSimple(h) },
+		func() (chapterLabel, bool) { return matchBatoPlain(h) },
+		func() (chapterLabel, bool) { return matchTitlePrefix(title) },
+		func() (chapterLabel, bool) { return matchChapRe(title) },
 	}
-	if n, typ, sn, label, ok := matchBatoPlain(h); ok {
-		return n, typ, sn, label, true
+
+	for _, m := range matchers {
+		if cl, ok := m(); ok {
+			if cl.Volume == 0 {
+				cl.Volume = parseVolume(h, title)
+			}
+			return withKind(cl, kind), true
+		}
 	}
-	if n, typ, sn, label, ok := matchTitlePrefix(title); ok {
-		return n, typ, sn, label, true
+
+	if kind == providers.KindRegular {
+		kind = findKind(h, title)
 	}
-	if n, typ, sn, label, ok := matchChapRe(title); ok {
-		return n, typ, sn, label, true
+	if kind != providers.KindRegular {
+		return withKind(chapterLabel{Volume: parseVolume(h, title)}, kind), true
 	}
 
-	return 0, "", 0, "", false
+	return chapterLabel{}, false
 }
 
 func hasChapterKeywords(h, t string) bool {
@@ -181,90 +272,161 @@ func hasChapterKeywords(h, t string) bool {
 		strings.Contains(h, "vol") ||
 		strings.Contains(t, "ch") ||
 		strings.Contains(t, "chapter") ||
-		strings.Contains(t, "vol")
+		strings.Contains(t, "vol") ||
+		providers.ParseKind(t) != providers.KindRegular
 }
 
 func isExcluded(h string) bool {
 	return strings.Contains(h, "/u/") || strings.Contains(h, "batolists")
 }
 
-func matchChapterDash(h string) (int, string, int, string, bool) {
+// detectKind finds extras and specials whose title or last path segment
+// (earlier segments often name the series) starts with the kind keyword,
+// after any volume prefix. "Chapter 45: Special Training" stays regular.
+func detectKind(h, title string) providers.ChapterKind {
+	t := strings.TrimSpace(reLeadingVolume.ReplaceAllString(strings.ToLower(strings.TrimSpace(title)), ""))
+	if k := providers.LeadingKind(t); k != providers.KindRegular {
+		return k
+	}
+
+	return providers.LeadingKind(reLeadingVolume.ReplaceAllString(lastSegment(h), ""))
+}
+
+// findKind looks for a kind keyword anywhere in the title or the last path
+// segment. It is only used for links no chapter number was found in.
+func findKind(h, title string) providers.ChapterKind {
+	if k := providers.ParseKind(title); k != providers.KindRegular {
+		return k
+	}
+
+	return providers.ParseKind(lastSegment(h))
+}
+
+func lastSegment(h string) string {
+	if u, err := url.Parse(h); err == nil {
+		return path.Base(strings.TrimSuffix(u.Path, "/"))
+	}
+
+	return h
+}
+
+func parseVolume(h, title string) int {
+	if v := providers.ParseVolume(title); v > 0 {
+		return v
+	}
+	if m := hrefVolume.FindStringSubmatch(h); m != nil {
+		v, _ := strconv.Atoi(m[1])
+		return v
+	}
+
+	return 0
+}
+
+// withKind relabels non-regular chapters so that e.g. "Extra 2" doesn't
+// collide with regular chapter 2.
+func withKind(cl chapterLabel, kind providers.ChapterKind) chapterLabel {
+	cl.Kind = kind
+	if kind == providers.KindRegular {
+		return cl
+	}
+
+	if cl.Label == "" {
+		cl.Label = string(kind)
+	} else {
+		cl.Label = string(kind) + "-" + cl.Label
+	}
+
+	return cl
+}
+
+func regularLabel(main int, typ string, sub string) chapterLabel {
+	cl := chapterLabel{NumMain: main, Number: float64(main)}
+	if typ == "" || sub == "" {
+		cl.Label = strconv.Itoa(main)
+		return cl
+	}
+
+	cl.SuffixType = typ
+	cl.SuffixNum, _ = strconv.Atoi(sub)
+	if typ == "." {
+		cl.Number, _ = strconv.ParseFloat(fmt.Sprintf("%d.%s", main, sub), 64)
+	} else {
+		sub = strconv.Itoa(cl.SuffixNum)
+	}
+	cl.Label = fmt.Sprintf("%d%s%s", main, typ, sub)
+
+	return cl
+}
+
+func splitDecimal(s string) (int, string) {
+	main, sub, _ := strings.Cut(s, ".")
+	n, _ := strconv.Atoi(main)
+
+	return n, sub
+}
+
+func matchChapterDash(h string) (chapterLabel, bool) {
 	if m := chapterDash.FindStringSubmatch(h); m != nil {
 		main, _ := strconv.Atoi(m[1])
-		if m[2] != "" {
-			sub, _ := strconv.Atoi(m[2])
 
-			return main, "-", sub, fmt.Sprintf("%d-%d", main, sub), true
-		}
-
-		return main, "", 0, fmt.Sprintf("%d", main), true
+		return regularLabel(main, "-", m[2]), true
 	}
 
-	return 0, "", 0, "", false
+	return chapterLabel{}, false
 }
 
-func matchBatoVol(h string) (int, string, int, string, bool) {
+func matchBatoVol(h string) (chapterLabel, bool) {
 	if m := batoVol.FindStringSubmatch(h); m != nil {
 		vol, _ := strconv.Atoi(m[1])
-		ch, _ := strconv.Atoi(m[2])
+		main, sub := splitDecimal(m[2])
+
+		cl := regularLabel(main, ".", sub)
+		cl.Volume = vol
 
-		return ch, ".", vol, fmt.Sprintf("%d.%d", vol, ch), true
+		return cl, true
 	}
 
-	return 0, "", 0, "", false
+	return chapterLabel{}, false
 }
 
-func matchBatoSimple(h string) (int, string, int, string, bool) {
+func matchBatoSimple(h string) (chapterLabel, bool) {
 	if m := batoSimple.FindStringSubmatch(h); m != nil {
-		parts := strings.Split(m[1], ".")
-		main, _ := strconv.Atoi(parts[0])
-		if len(parts) == 2 {
-			sub, _ := strconv.Atoi(parts[1])
-
-			return main, ".", sub, fmt.Sprintf("%d.%d", main, sub), true
-		}
+		main, sub := splitDecimal(m[1])
 
-		return main, "", 0, fmt.Sprintf("%d", main), true
+		return regularLabel(main, ".", sub), true
 	}
 
-	return 0, "", 0, "", false
+	return chapterLabel{}, false
 }
 
-func matchBatoPlain(h string) (int, string, int, string, bool) {
+func matchBatoPlain(h string) (chapterLabel, bool) {
 	if m := batoPlain.FindStringSubmatch(h); m != nil {
-		n, _ := strconv.Atoi(m[1])
+		main, sub := splitDecimal(m[1])
 
-		return n, "", 0, m[1], true
+		return regularLabel(main, ".", sub), true
 	}
 
-	return 0, "", 0, "", false
+	return chapterLabel{}, false
 }
 
-func matchTitlePrefix(title string) (int, string, int, string, bool) {
+func matchTitlePrefix(title string) (chapterLabel, bool) {
 	if m := titlePrefix.FindStringSubmatch(title); m != nil {
-		n, _ := strconv.Atoi(m[1])
+		main, sub := splitDecimal(m[1])
 
-		return n, "", 0, m[1], true
+		return regularLabel(main, ".", sub), true
 	}
 
-	return 0, "", 0, "", false
+	return chapterLabel{}, false
 }
 
-func matchChapRe(title string) (int, string, int, string, bool) {
+func matchChapRe(title string) (chapterLabel, bool) {
 	if m := chapRe.FindStringSubmatch(title); m != nil {
 		main, _ := strconv.Atoi(m[1])
-		typ := m[2]
-		sub, _ := strconv.Atoi(m[3])
-		label := fmt.Sprintf("%d%s%d", main, typ, sub)
 
-		if typ == "" {
-			label = fmt.Sprintf("%d", main)
-		}
-
-		return main, typ, sub, label, true
+		return regularLabel(main, m[2], m[3]), true
 	}
 
-	return 0, "", 0, "", false
+	return chapterLabel{}, false
 }
 
 func looksLikeChapterLink(href, title string) bool {
@@ -273,9 +435,16 @@ func looksLikeChapterLink(href, title string) bool {
 		return true
 	}
 
-	t := strings.ToLower(title)
+	t := strings.ToLower(strings.TrimSpace(title))
+	if strings.HasPrefix(t, "ch ") || strings.HasPrefix(t, "chapter ") {
+		return true
+	}
+
+	// "Extra 2", "Side Story", "Vol.3 Omake" etc. only count when the kind
+	// keyword leads the link text, so nav links like "All Specials" stay out.
+	t = strings.TrimSpace(reLeadingVolume.ReplaceAllString(t, ""))
 
-	return strings.HasPrefix(t, "ch ") || strings.HasPrefix(t, "chapter ")
+	return providers.LeadingKind(t) != providers.KindRegular
 }
 
 func resolveURL(baseURL, href string) string {
@@ -302,55 +471,118 @@ func (s *Scraper) GetChapters(ctx context.Context, pageURL string) ([]providers.
 		return nil, err
 	}
 
-	var out []providers.Chapter
-	seen := map[string]bool{}
+	return s.collectChapters(doc, pageURL, nil), nil
+}
+
+// collectChapters extracts chapter links from doc. When rep is non-nil
+// every anchor and the decision taken on it is recorded for inspect.
+func (s *Scraper) collectChapters(doc *goquery.Document, pageURL string, rep *Report) []providers.Chapter {
+	decide := func(href, text string, accepted bool, reason string, label string) int {
+		if rep == nil {
+			return -1
+		}
+		rep.Links = append(rep.Links, ChapterLink{Href: href, Text: cleanText(text), Accepted: accepted, Reason: reason, Label: label})
+		return len(rep.Links) - 1
+	}
+
+	var cands []chapterCandidate
 
 	doc.Find("a[href]").Each(func(_ int, a *goquery.Selection) {
 		href, _ := a.Attr("href")
 		if !looksLikeChapterLink(href, a.Text()) {
+			decide(href, a.Text(), false, "looksLikeChapterLink: no chapter pattern in href or text", "")
 			return
 		}
 
-		n, t, sn, label, ok := parseChapterLabel(strings.TrimSpace(href), strings.TrimSpace(a.Text()))
+		cl, ok := parseChapterLabel(strings.TrimSpace(href), strings.TrimSpace(a.Text()))
 		if !ok {
+			decide(href, a.Text(), false, "parseChapterLabel: no chapter number found or excluded path", "")
 			return
 		}
 
-		u := resolveURL(pageURL, href)
-		if seen[u] {
-			return
+		cands = append(cands, chapterCandidate{
+			a:     a,
+			label: cl,
+			url:   resolveURL(pageURL, href),
+			link:  decide(href, a.Text(), true, "chapter "+cl.Label, cl.Label),
+		})
+	})
+
+	picked, cluster := pickChapterCluster(cands, pageURL)
+	if cluster != nil {
+		s.log.Debugf("Chapter list container: %s; ignored %d links outside it\n", cluster, len(cands)-len(picked))
+	} else {
+		s.log.Debugf("No dominant chapter list container, keeping all %d chapter links\n", len(cands))
+	}
+
+	if rep != nil {
+		inCluster := make(map[int]bool, len(picked))
+		for _, i := range picked {
+			inCluster[i] = true
+		}
+		for i, c := range cands {
+			rep.Links[c.link].Container = c.cluster
+			if !inCluster[i] {
+				rep.Links[c.link].Accepted = false
+				rep.Links[c.link].Reason = "outside the chapter list container"
+			}
+		}
+		if cluster != nil {
+			rep.Container = cluster.String()
+		}
+	}
+
+	var out []providers.Chapter
+	seen := map[string]bool{}
+	now := time.Now()
+
+	for _, i := range picked {
+		c := cands[i]
+		if seen[c.url] {
+			if rep != nil {
+				rep.Links[c.link].Accepted = false
+				rep.Links[c.link].Reason = "duplicate URL"
+			}
+			continue
 		}
-		seen[u] = true
+		seen[c.url] = true
 
-		title := strings.TrimSpace(a.Text())
+		href, _ := c.a.Attr("href")
+		title := strings.TrimSpace(c.a.Text())
 		if title == "" {
-			title = "Chapter " + label
+			title = "Chapter " + c.label.Label
 		}
 
+		group, lang := extractGroupLang(c.a, href)
+
 		out = append(out, providers.Chapter{
-			URL:        u,
+			URL:        c.url,
 			Title:      title,
-			NumMain:    n,
-			SuffixType: t,
-			SuffixNum:  sn,
-			Label:      label,
+			NumMain:    c.label.NumMain,
+			SuffixType: c.label.SuffixType,
+			SuffixNum:  c.label.SuffixNum,
+			Label:      c.label.Label,
+			Volume:     c.label.Volume,
+			Number:     c.label.Number,
+			Kind:       c.label.Kind,
+			Group:      group,
+			Language:   lang,
+
+			Date:        extractDate(c.a, now),
+			SourceIndex: len(out),
 		})
-	})
+	}
 
-	sort.SliceStable(out, func(i, j int) bool {
-		if out[i].NumMain != out[j].NumMain {
-			return out[i].NumMain < out[j].NumMain
-		}
-		if out[i].SuffixType != out[j].SuffixType {
-			return out[i].SuffixType < out[j].SuffixType
-		}
-		return out[i].SuffixNum < out[j].SuffixNum
-	})
+	providers.SortChapters(out)
 
-	return out, nil
+	return out
 }
 
 func (s *Scraper) GetImages(ctx context.Context, chapterURL string) ([]string, error) {
+	return s.collectImages(ctx, chapterURL, newImageCollector(s.allowed, s.log.DebugEnabled()))
+}
+
+func (s *Scraper) collectImages(ctx context.Context, chapterURL string, col *imageCollector) ([]string, error) {
 	doc, err := s.fetchDOM(ctx, chapterURL)
 	if err != nil {
 		return nil, err
@@ -360,8 +592,6 @@ func (s *Scraper) GetImages(ctx context.Context, chapterURL string) ([]string, e
 
 	// s.log.Debugf("\n======= DEBUG HTML START =======\n%s\n======= DEBUG HTML END =======\n\n", body)
 
-	col := newImageCollector(s.allowed, s.log.Debug)
-
 	added := col.ScanIMGTags(doc, chapterURL)
 	s.log.Debugf("IMG tags: +%d candidates\n", added)
 
@@ -382,6 +612,9 @@ func (s *Scraper) GetImages(ctx context.Context, chapterURL string) ([]string, e
 		}
 	}
 
+	added = col.ScanScripts(doc, chapterURL)
+	s.log.Debugf("JS literals: +%d candidates\n", added)
+
 	col.ScanLooseURLs(body)
 
 	if s.checkJS {
@@ -411,5 +644,17 @@ func (s *Scraper) GetImages(ctx context.Context, chapterURL string) ([]string, e
 		return nil, fmt.Errorf("no usable images found")
 	}
 
+	f := &imageFilter{client: s.client, block: s.block, probe: s.probe, referer: chapterURL}
+	final, dropped := f.Apply(ctx, final)
+	for _, d := range dropped {
+		s.log.Trace("Dropped image", "url", d.URL, "reason", d.Reason)
+		col.markDropped(d.URL, d.Reason)
+	}
+	s.log.Debugf("Filtering: kept %d, dropped %d candidates\n", len(final), len(dropped))
+
+	if len(final) == 0 {
+		return nil, fmt.Errorf("no usable images found (all %d candidates filtered, see --debug)", len(dropped))
+	}
+
 	return final, nil
 }
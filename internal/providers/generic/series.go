@@ -0,0 +1,96 @@
+package generic
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/brogergvhs/mangad/internal/providers"
+)
+
+var (
+	// reTitleSuffix matches site boilerplate after the series title, e.g.
+	// " - Read Manga Online Free | Site".
+	reTitleSuffix = regexp.MustCompile(`(?i)\s+[-–—|:]\s+.*\b(read|manga|manhwa|manhua|online|free|chapters?|scans?)\b.*$`)
+	reTitleRead   = regexp.MustCompile(`(?i)^read\s+`)
+	reTitleManga  = regexp.MustCompile(`(?i)\s+(manga|manhwa|manhua)(\s+online)?$`)
+)
+
+// GetSeries reads the title, description and cover of a series page.
+func (s *Scraper) GetSeries(ctx context.Context, seriesURL string) (providers.Series, error) {
+	doc, err := s.fetchDOM(ctx, seriesURL)
+	if err != nil {
+		return providers.Series{}, err
+	}
+
+	info := providers.Series{
+		URL:         seriesURL,
+		Title:       seriesTitle(doc),
+		Description: firstMeta(doc, `meta[property="og:description"]`, `meta[name="description"]`),
+	}
+
+	if cover := firstMeta(doc, `meta[property="og:image"]`, `meta[name="twitter:image"]`); cover != "" {
+		info.Cover = resolveURL(seriesURL, cover)
+	} else {
+		doc.Find("img").EachWithBreak(func(_ int, img *goquery.Selection) bool {
+			hint := strings.ToLower(img.AttrOr("class", "") + " " + img.AttrOr("alt", "") + " " + img.Parent().AttrOr("class", ""))
+			if !strings.Contains(hint, "cover") && !strings.Contains(hint, "poster") && !strings.Contains(hint, "thumb") {
+				return true
+			}
+			if src := firstNonEmptyAttr(img, "data-src", "data-lazy-src", "src"); src != "" {
+				info.Cover = resolveURL(seriesURL, src)
+				return false
+			}
+			return true
+		})
+	}
+
+	s.log.Debug("Series info", "url", seriesURL, "title", info.Title, "cover", info.Cover)
+	return info, nil
+}
+
+// seriesTitle prefers a single <h1>, then og:title, then <title>, with site
+// boilerplate removed.
+func seriesTitle(doc *goquery.Document) string {
+	var candidates []string
+	if h1 := doc.Find("h1"); h1.Length() == 1 {
+		candidates = append(candidates, h1.Text())
+	}
+	candidates = append(candidates,
+		firstMeta(doc, `meta[property="og:title"]`, `meta[name="twitter:title"]`),
+		doc.Find("title").First().Text(),
+	)
+
+	for _, c := range candidates {
+		c = strings.Join(strings.Fields(c), " ")
+		c = reTitleSuffix.ReplaceAllString(c, "")
+		c = reTitleRead.ReplaceAllString(c, "")
+		c = reTitleManga.ReplaceAllString(c, "")
+		if c = strings.TrimSpace(c); c != "" && len(c) <= 200 {
+			return c
+		}
+	}
+
+	return ""
+}
+
+func firstMeta(doc *goquery.Document, selectors ...string) string {
+	for _, sel := range selectors {
+		if v := strings.TrimSpace(doc.Find(sel).First().AttrOr("content", "")); v != "" {
+			return v
+		}
+	}
+
+	return ""
+}
+
+func firstNonEmptyAttr(sel *goquery.Selection, attrs ...string) string {
+	for _, a := range attrs {
+		if v := strings.TrimSpace(sel.AttrOr(a, "")); v != "" {
+			return v
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,136 @@
+package generic
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/brogergvhs/mangad/internal/fetcher"
+	"github.com/brogergvhs/mangad/internal/ui"
+	"github.com/brogergvhs/mangad/internal/util"
+)
+
+// stubFetcher answers every request with a canned browser session.
+type stubFetcher struct {
+	resp fetcher.Response
+	got  fetcher.Request
+}
+
+func (f *stubFetcher) Fetch(_ context.Context, req fetcher.Request) (*fetcher.Response, error) {
+	f.got = req
+	resp := f.resp
+	return &resp, nil
+}
+
+// recorder is the client's base transport: it remembers the User-Agent
+// and cookies each host was sent, without touching the network.
+type recorder struct {
+	ua      map[string]string
+	cookies map[string]string
+}
+
+func (r *recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	r.ua[req.URL.Hostname()] = req.Header.Get("User-Agent")
+	r.cookies[req.URL.Hostname()] = req.Header.Get("Cookie")
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("")), Request: req}, nil
+}
+
+func TestAdoptBrowserSession(t *testing.T) {
+	const browserUA = "Mozilla/5.0 (stub browser)"
+
+	rec := &recorder{ua: map[string]string{}, cookies: map[string]string{}}
+	client, err := util.NewHTTPClient(util.HTTPClientOptions{UserAgent: "mangad-test", Transport: rec})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stub := &stubFetcher{resp: fetcher.Response{
+		HTML:      "<html></html>",
+		URL:       "https://www.example.com/manga/1",
+		UserAgent: browserUA,
+		Cookies: []fetcher.Cookie{
+			{Name: "cf_clearance", Value: "token", Domain: ".example.com", Path: "/"},
+		},
+	}}
+	scr, err := NewScraper(client, ui.NewLogger(false), ScraperOptions{Fetcher: stub})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := scr.fetchExternal(context.Background(), "https://www.example.com/manga/1"); err != nil {
+		t.Fatal(err)
+	}
+	if stub.got.URL != "https://www.example.com/manga/1" {
+		t.Errorf("fetcher got URL %q", stub.got.URL)
+	}
+
+	u, _ := url.Parse("https://cdn.example.com/img/1.jpg")
+	if cs := client.Jar.Cookies(u); len(cs) != 1 || cs[0].Name != "cf_clearance" || cs[0].Value != "token" {
+		t.Errorf("jar cookies for %s = %v, want cf_clearance=token", u.Host, cs)
+	}
+
+	for _, host := range []string{"example.com", "www.example.com", "cdn.example.com", "badexample.com", "other.org"} {
+		req, _ := http.NewRequest(http.MethodGet, "https://"+host+"/", nil)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+	}
+
+	for host, want := range map[string]string{
+		"example.com":     browserUA,
+		"www.example.com": browserUA,
+		"cdn.example.com": browserUA,
+		"badexample.com":  "mangad-test",
+		"other.org":       "mangad-test",
+	} {
+		if got := rec.ua[host]; got != want {
+			t.Errorf("User-Agent for %s = %q, want %q", host, got, want)
+		}
+	}
+	if c := rec.cookies["cdn.example.com"]; !strings.Contains(c, "cf_clearance=token") {
+		t.Errorf("cookies sent to cdn.example.com = %q, want the clearance cookie", c)
+	}
+	if c := rec.cookies["badexample.com"]; c != "" {
+		t.Errorf("cookies sent to badexample.com = %q, want none", c)
+	}
+}
+
+func TestAdoptBrowserSessionHostOnly(t *testing.T) {
+	rec := &recorder{ua: map[string]string{}, cookies: map[string]string{}}
+	client, err := util.NewHTTPClient(util.HTTPClientOptions{UserAgent: "mangad-test", Transport: rec})
+	if err != nil {
+		t.Fatal(err)
+	}
+	scr, err := NewScraper(client, ui.NewLogger(false), ScraperOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a cookie for a look-alike domain must not widen the UA binding
+	scr.adoptBrowserSession(&fetcher.Response{
+		URL:       "https://badexample.com/manga/1",
+		UserAgent: "stub",
+		Cookies:   []fetcher.Cookie{{Name: "a", Value: "b", Domain: "example.com"}},
+	})
+
+	for host, want := range map[string]string{
+		"badexample.com":     "stub",
+		"img.badexample.com": "stub",
+		"example.com":        "mangad-test",
+	} {
+		req, _ := http.NewRequest(http.MethodGet, "https://"+host+"/", nil)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+		if got := rec.ua[host]; got != want {
+			t.Errorf("User-Agent for %s = %q, want %q", host, got, want)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package providers
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type ChapterKind string
+
+const (
+	KindRegular  ChapterKind = "regular"
+	KindExtra    ChapterKind = "extra"
+	KindSpecial  ChapterKind = "special"
+	KindOneshot  ChapterKind = "oneshot"
+	KindPrologue ChapterKind = "prologue"
+	KindEpilogue ChapterKind = "epilogue"
+)
+
+var (
+	kindPatterns = []struct {
+		kind ChapterKind
+		re   *regexp.Regexp
+	}{
+		{KindPrologue, regexp.MustCompile(`(?i)\bprologue\b`)},
+		{KindEpilogue, regexp.MustCompile(`(?i)\bepilogue\b`)},
+		{KindOneshot, regexp.MustCompile(`(?i)\bone[\s_\-]?shot\b`)},
+		{KindExtra, regexp.MustCompile(`(?i)\b(?:extras?|side[\s_\-]?stor(?:y|ies)|omake|bonus|afterword)\b`)},
+		{KindSpecial, regexp.MustCompile(`(?i)\bspecials?\b`)},
+	}
+
+	reVolume = regexp.MustCompile(`(?i)\bv(?:ol(?:ume)?)?\.?[\s_\-]*0*(\d+)\b`)
+)
+
+// ParseKind detects extras, specials and similar non-regular chapters
+// from a link title or URL segment.
+func ParseKind(s string) ChapterKind {
+	for _, p := range kindPatterns {
+		if p.re.MatchString(s) {
+			return p.kind
+		}
+	}
+
+	return KindRegular
+}
+
+// LeadingKind is like ParseKind but only accepts a keyword at the very
+// start of s ("Extra 2" but not "All Extras").
+func LeadingKind(s string) ChapterKind {
+	s = strings.TrimSpace(s)
+	for _, p := range kindPatterns {
+		if loc := p.re.FindStringIndex(s); loc != nil && loc[0] == 0 {
+			return p.kind
+		}
+	}
+
+	return KindRegular
+}
+
+// ParseVolume extracts a "Vol. 3" / "vol_3" / "v3" volume number, 0 if none.
+func ParseVolume(s string) int {
+	if m := reVolume.FindStringSubmatch(s); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		return n
+	}
+
+	return 0
+}
+
+// IsNumbered reports whether the chapter carries a usable chapter number.
+func (c Chapter) IsNumbered() bool {
+	return c.Number > 0 || (c.Kind == KindRegular || c.Kind == "") && c.Label != ""
+}
+
+func kindRank(k ChapterKind) int {
+	switch k {
+	case KindPrologue:
+		return 0
+	case KindRegular, "":
+		return 1
+	case KindSpecial:
+		return 2
+	case KindExtra:
+		return 3
+	case KindEpilogue:
+		return 4
+	default:
+		return 5
+	}
+}
+
+// SortChapters orders chapters by volume (only when every chapter has one),
+// chapter number, kind and suffix. Unnumbered prologues go first and other
+// unnumbered extras go last, keeping their page order.
+func SortChapters(list []Chapter) {
+	byVolume := len(list) > 0
+	for _, c := range list {
+		if c.Volume == 0 {
+			byVolume = false
+			break
+		}
+	}
+
+	bucket := func(c Chapter) int {
+		if c.IsNumbered() {
+			return 1
+		}
+		if c.Kind == KindPrologue {
+			return 0
+		}
+		return 2
+	}
+
+	sort.SliceStable(list, func(i, j int) bool {
+		a, b := list[i], list[j]
+
+		if ba, bb := bucket(a), bucket(b); ba != bb {
+			return ba < bb
+		}
+		if bucket(a) != 1 {
+			return false
+		}
+		if byVolume && a.Volume != b.Volume {
+			return a.Volume < b.Volume
+		}
+		if a.Number != b.Number {
+			return a.Number < b.Number
+		}
+		if ra, rb := kindRank(a.Kind), kindRank(b.Kind); ra != rb {
+			return ra < rb
+		}
+		if a.SuffixType != b.SuffixType {
+			return a.SuffixType < b.SuffixType
+		}
+		return a.SuffixNum < b.SuffixNum
+	})
+}
+
+// MatchesLabel reports whether a user-supplied chapter query refers to c.
+// Besides exact labels it understands "vol3/27", "v3 ch27", "12.50",
+// "ch 12" and kind names such as "extra" or "extra-2".
+func (c Chapter) MatchesLabel(query string) bool {
+	q := strings.ToLower(strings.TrimSpace(query))
+	if q == "" {
+		return false
+	}
+	if strings.EqualFold(c.Label, q) {
+		return true
+	}
+
+	if vol := ParseVolume(q); vol > 0 {
+		if c.Volume != vol {
+			return false
+		}
+		q = strings.TrimSpace(reVolume.ReplaceAllString(q, ""))
+		q = strings.TrimLeft(q, ":/ _-")
+		if q == "" {
+			return false
+		}
+		if strings.EqualFold(c.Label, q) {
+			return true
+		}
+	}
+
+	q = strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(q, "chapter"), "ch"))
+	q = strings.TrimSpace(strings.TrimPrefix(q, "."))
+
+	if k := ParseKind(q); k != KindRegular {
+		if c.Kind != k {
+			return false
+		}
+		rest := strings.Trim(kindPatterns[kindIndex(k)].re.ReplaceAllString(q, ""), " _-")
+		if rest == "" {
+			return true
+		}
+		n, err := strconv.ParseFloat(rest, 64)
+		return err == nil && n == c.Number
+	}
+
+	if c.Kind != KindRegular && c.Kind != "" || c.SuffixType == "-" {
+		return false
+	}
+	n, err := strconv.ParseFloat(q, 64)
+
+	return err == nil && c.IsNumbered() && n == c.Number
+}
+
+func kindIndex(k ChapterKind) int {
+	for i, p := range kindPatterns {
+		if p.kind == k {
+			return i
+		}
+	}
+
+	return 0
+}
+
+// FormatNumber renders a chapter number without trailing zeros ("12", "12.5").
+func FormatNumber(n float64) string {
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
@@ -0,0 +1,38 @@
+package providers
+
+import "strings"
+
+var (
+	// countryToLang maps the flag country codes sites commonly use to
+	// language codes.
+	countryToLang = map[string]string{
+		"gb": "en", "us": "en", "uk": "en",
+		"jp": "ja", "kr": "ko", "cn": "zh", "hk": "zh-hk", "tw": "zh-hk",
+		"br": "pt-br", "mx": "es-la", "sa": "ar", "vn": "vi",
+	}
+
+	langNames = map[string]string{
+		"english": "en", "spanish": "es", "french": "fr", "german": "de",
+		"italian": "it", "portuguese": "pt", "russian": "ru", "indonesian": "id",
+		"vietnamese": "vi", "thai": "th", "turkish": "tr", "polish": "pl",
+		"arabic": "ar", "japanese": "ja", "korean": "ko", "chinese": "zh",
+	}
+)
+
+// NormalizeLang turns a language name, code or flag country code into a
+// lowercase language code ("English" and "gb" give "en", "pt_BR" gives
+// "pt-br"). Unknown values are only lowercased.
+func NormalizeLang(v string) string {
+	v = strings.ToLower(strings.TrimSpace(strings.ReplaceAll(v, "_", "-")))
+	if v == "" {
+		return ""
+	}
+	if l, ok := langNames[v]; ok {
+		return l
+	}
+	if l, ok := countryToLang[v]; ok {
+		return l
+	}
+
+	return v
+}
@@ -1,14 +1,36 @@
 package providers
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 type Chapter struct {
-	URL        string
-	Title      string
-	NumMain    int
-	SuffixType string
-	SuffixNum  int
-	Label      string
+	URL        string `json:"url"`
+	Title      string `json:"title"`
+	NumMain    int    `json:"num_main"`
+	SuffixType string `json:"suffix_type,omitempty"`
+	SuffixNum  int    `json:"suffix_num,omitempty"`
+	Label      string `json:"label"`
+
+	Volume int         `json:"volume,omitempty"` // 0 if the source doesn't expose volumes
+	Number float64     `json:"number"`           // decimal chapter number (e.g. 12.5), 0 if unnumbered
+	Kind   ChapterKind `json:"kind"`             // regular, extra, special, ...
+
+	Group    string `json:"group,omitempty"`    // scanlation group, if listed
+	Language string `json:"language,omitempty"` // lowercase language code (e.g. "en", "pt-br"), if listed
+
+	Date        time.Time `json:"date,omitzero"` // release date, zero when the site doesn't show one
+	SourceIndex int       `json:"source_index"`  // position on the page before sorting
+}
+
+// Series is what a series page tells about the series itself. Fields the
+// page doesn't expose are empty.
+type Series struct {
+	URL         string `json:"url"`
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Cover       string `json:"cover,omitempty"` // cover image URL
 }
 
 type Scraper interface {
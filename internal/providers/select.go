@@ -38,6 +38,15 @@ func FilterByLabel(all []Chapter, label string) []Chapter {
 			out = append(out, c)
 		}
 	}
+	if len(out) > 0 {
+		return out
+	}
+
+	for _, c := range all {
+		if c.MatchesLabel(label) {
+			out = append(out, c)
+		}
+	}
 
 	return out
 }
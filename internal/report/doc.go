@@ -0,0 +1,4 @@
+// Package report classifies chapter download failures and keeps them in a
+// failures file in the output folder, so a later run can retry exactly the
+// chapters that failed.
+package report
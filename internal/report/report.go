@@ -0,0 +1,224 @@
+package report
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/brogergvhs/mangad/internal/downloader"
+	"github.com/brogergvhs/mangad/internal/providers/generic"
+	"github.com/brogergvhs/mangad/internal/util"
+)
+
+// FileName is the failures file written to the output folder.
+const FileName = "mangad-failures.json"
+
+type Kind string
+
+const (
+	KindNoImages   Kind = "no_images"
+	KindHTTP       Kind = "http_error"
+	KindCloudflare Kind = "cloudflare_blocked"
+	KindCBZ        Kind = "cbz_failed"
+	KindPartial    Kind = "partial_pages"
+	KindOther      Kind = "error"
+)
+
+// Failure is one chapter that could not be downloaded completely.
+type Failure struct {
+	Series  string    `json:"series"`
+	Chapter string    `json:"chapter"` // chapter label
+	Title   string    `json:"title,omitempty"`
+	URL     string    `json:"url"`
+	Kind    Kind      `json:"kind"`
+	Status  int       `json:"status,omitempty"` // HTTP status for http_error
+	Pages   []int     `json:"pages,omitempty"`  // missing pages for partial_pages
+	Reason  string    `json:"reason"`
+	Time    time.Time `json:"time"`
+}
+
+// Classify fills in Kind, Status, Pages and Reason from err. fallback is
+// used when err doesn't tell what went wrong (or is nil).
+func (f Failure) Classify(err error, fallback Kind) Failure {
+	f.Kind = fallback
+	f.Time = time.Now()
+	if err == nil {
+		f.Reason = string(fallback)
+		return f
+	}
+	f.Reason = err.Error()
+
+	var partial *downloader.PartialError
+	var httpErr *util.HTTPError
+	switch {
+	case errors.As(err, &partial):
+		f.Kind, f.Pages = KindPartial, partial.Pages
+	case errors.Is(err, generic.ErrCloudflareBlocked):
+		f.Kind = KindCloudflare
+	case errors.As(err, &httpErr):
+		f.Kind, f.Status = KindHTTP, httpErr.Status
+	}
+
+	return f
+}
+
+// File is the content of the failures file. It may hold failures of
+// several series that share an output folder.
+type File struct {
+	Updated  time.Time `json:"updated"`
+	Failures []Failure `json:"failures"`
+}
+
+func Path(dir string) string {
+	return filepath.Join(dir, FileName)
+}
+
+// Load reads the failures file in dir. A missing file is an empty report.
+func Load(dir string) (*File, error) {
+	b, err := os.ReadFile(Path(dir))
+	if errors.Is(err, os.ErrNotExist) {
+		return &File{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var f File
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", Path(dir), err)
+	}
+
+	return &f, nil
+}
+
+// Series returns the failures of one series.
+func (f *File) Series(series string) []Failure {
+	var out []Failure
+	for _, fl := range f.Failures {
+		if fl.Series == series {
+			out = append(out, fl)
+		}
+	}
+
+	return out
+}
+
+// OnlySeries returns the series URL when all failures belong to one series.
+func (f *File) OnlySeries() string {
+	series := ""
+	for _, fl := range f.Failures {
+		if series != "" && fl.Series != series {
+			return ""
+		}
+		series = fl.Series
+	}
+
+	return series
+}
+
+// updateMu serializes Update, since queue entries that share an output
+// folder finish at the same time.
+var updateMu sync.Mutex
+
+// Update replaces the failures of series in the failures file in dir. The
+// file is removed once it holds no failures.
+func Update(dir, series string, failures []Failure) error {
+	updateMu.Lock()
+	defer updateMu.Unlock()
+
+	f, err := Load(dir)
+	if err != nil {
+		return err
+	}
+
+	kept := append([]Failure{}, failures...)
+	for _, fl := range f.Failures {
+		if fl.Series != series {
+			kept = append(kept, fl)
+		}
+	}
+
+	if len(kept) == 0 {
+		if err := os.Remove(Path(dir)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+
+	f.Updated, f.Failures = time.Now(), kept
+	b, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return writeFile(Path(dir), append(b, '\n'))
+}
+
+// writeFile writes b next to path and renames it into place, so an
+// interrupted write doesn't leave a truncated failures file.
+func writeFile(path string, b []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(b); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// Print writes a failures section for the summary: a count per kind and one
+// line per chapter.
+func Print(w io.Writer, failures []Failure) {
+	counts := map[Kind]int{}
+	for _, f := range failures {
+		counts[f.Kind]++
+	}
+	kinds := make([]string, 0, len(counts))
+	for k, n := range counts {
+		kinds = append(kinds, fmt.Sprintf("%s: %d", k, n))
+	}
+	sort.Strings(kinds)
+
+	_, _ = fmt.Fprintf(w, "Failed:   %d (%s)\n", len(failures), strings.Join(kinds, ", "))
+	for _, f := range failures {
+		detail := f.Reason
+		if len(f.Pages) > 0 {
+			detail = fmt.Sprintf("pages %s: %s", pageList(f.Pages), f.Reason)
+		}
+		_, _ = fmt.Fprintf(w, "  %-12s %-18s %s\n", f.Chapter, f.Kind, detail)
+	}
+}
+
+// pageList renders page numbers, shortening long lists.
+func pageList(pages []int) string {
+	const show = 10
+
+	parts := make([]string, 0, min(len(pages), show))
+	for i, p := range pages {
+		if i == show {
+			parts = append(parts, fmt.Sprintf("… +%d", len(pages)-show))
+			break
+		}
+		parts = append(parts, fmt.Sprint(p))
+	}
+
+	return strings.Join(parts, ",")
+}
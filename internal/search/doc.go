@@ -0,0 +1,3 @@
+// Package search queries sites' own search pages or APIs using the site
+// definitions from the config and turns the hits into series URLs.
+package search
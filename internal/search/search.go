@@ -0,0 +1,218 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/brogergvhs/mangad/internal/config"
+)
+
+// FetchFunc returns the body of a URL.
+type FetchFunc func(ctx context.Context, target string) (string, error)
+
+type Result struct {
+	Site  string `json:"site"`
+	Title string `json:"title"`
+	URL   string `json:"url"`
+	Cover string `json:"cover,omitempty"`
+}
+
+var rePlaceholder = regexp.MustCompile(`\{([^{}]+)\}`)
+
+// Search runs one query against one site.
+func Search(ctx context.Context, fetch FetchFunc, site config.SearchSite, query string) ([]Result, error) {
+	target := strings.ReplaceAll(site.SearchURL, "{query}", url.QueryEscape(query))
+
+	body, err := fetch(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []Result
+	if site.JSONPath != "" {
+		out, err = parseJSON(body, site, target)
+	} else {
+		out, err = parseHTML(body, site, target)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", site.Name, err)
+	}
+
+	return out, nil
+}
+
+func parseHTML(body string, site config.SearchSite, base string) ([]Result, error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	var out []Result
+	doc.Find(site.Results).Each(func(_ int, item *goquery.Selection) {
+		title := htmlField(item, site.Title)
+		link := htmlField(item, site.URL)
+		if site.URL == "" {
+			link = item.Find("a[href]").First().AttrOr("href", item.AttrOr("href", ""))
+		}
+		cover := htmlField(item, site.Cover)
+		if site.Cover == "" {
+			img := item.Find("img").First()
+			cover = img.AttrOr("data-src", img.AttrOr("src", ""))
+		}
+		if title == "" && site.Title == "" {
+			title = cleanText(item.Find("a[href]").First().Text())
+		}
+
+		if link == "" {
+			return
+		}
+		out = append(out, Result{
+			Site:  site.Name,
+			Title: title,
+			URL:   resolve(base, link),
+			Cover: resolve(base, cover),
+		})
+	})
+
+	return out, nil
+}
+
+// htmlField reads "selector", "selector@attr" or "@attr" from a result.
+func htmlField(item *goquery.Selection, spec string) string {
+	if spec == "" {
+		return ""
+	}
+
+	sel, attr, hasAttr := strings.Cut(spec, "@")
+	target := item
+	if sel = strings.TrimSpace(sel); sel != "" {
+		target = item.Find(sel).First()
+	}
+
+	if hasAttr {
+		return strings.TrimSpace(target.AttrOr(attr, ""))
+	}
+
+	return cleanText(target.Text())
+}
+
+func parseJSON(body string, site config.SearchSite, base string) ([]Result, error) {
+	var root any
+	if err := json.Unmarshal([]byte(body), &root); err != nil {
+		return nil, fmt.Errorf("invalid JSON response: %w", err)
+	}
+
+	items, ok := lookup(root, site.JSONPath).([]any)
+	if !ok {
+		return nil, fmt.Errorf("json_path %q is not a list", site.JSONPath)
+	}
+
+	var out []Result
+	for _, item := range items {
+		link := jsonField(item, site.URL)
+		if link == "" {
+			continue
+		}
+		out = append(out, Result{
+			Site:  site.Name,
+			Title: jsonField(item, site.Title),
+			URL:   resolve(base, link),
+			Cover: resolve(base, jsonField(item, site.Cover)),
+		})
+	}
+
+	return out, nil
+}
+
+// jsonField reads a dot path from a result, or fills a template whose
+// {placeholders} are dot paths.
+func jsonField(item any, spec string) string {
+	if spec == "" {
+		return ""
+	}
+
+	if !strings.Contains(spec, "{") {
+		return scalar(lookup(item, spec))
+	}
+
+	return rePlaceholder.ReplaceAllStringFunc(spec, func(m string) string {
+		return url.PathEscape(scalar(lookup(item, m[1:len(m)-1])))
+	})
+}
+
+// lookup follows a dot path such as "data.0.attributes.title"; "." and ""
+// return v itself.
+func lookup(v any, path string) any {
+	if path == "" || path == "." {
+		return v
+	}
+
+	for _, key := range strings.Split(path, ".") {
+		switch node := v.(type) {
+		case map[string]any:
+			v = node[key]
+		case []any:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil
+			}
+			v = node[i]
+		default:
+			return nil
+		}
+	}
+
+	return v
+}
+
+func scalar(v any) string {
+	switch x := v.(type) {
+	case string:
+		return strings.TrimSpace(x)
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(x)
+	case map[string]any:
+		// localized titles, e.g. {"en": "..."}: take the English or any value
+		if s, ok := x["en"].(string); ok {
+			return s
+		}
+		for _, k := range slices.Sorted(maps.Keys(x)) {
+			if s, ok := x[k].(string); ok {
+				return s
+			}
+		}
+	}
+
+	return ""
+}
+
+func resolve(base, ref string) string {
+	if ref == "" {
+		return ""
+	}
+
+	b, err := url.Parse(base)
+	if err != nil {
+		return ref
+	}
+	r, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+
+	return b.ResolveReference(r).String()
+}
+
+func cleanText(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
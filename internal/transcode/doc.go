@@ -0,0 +1,5 @@
+// Package transcode converts downloaded pages before they are archived:
+// WebP to JPEG or PNG and PNG to JPEG for readers that can't open them,
+// and optionally strips EXIF, XMP and text metadata from the pages that are
+// kept as they are.
+package transcode
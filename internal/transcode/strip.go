@@ -0,0 +1,132 @@
+package transcode
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// strip removes metadata from a JPEG, PNG or WebP file without re-encoding
+// it. It reports false when the format isn't handled, the file has nothing
+// to remove or its structure isn't understood.
+func strip(format string, data []byte) ([]byte, bool) {
+	var out []byte
+	switch format {
+	case "jpg":
+		out = stripJPEG(data)
+	case "png":
+		out = stripPNG(data)
+	case "webp":
+		out = stripWebP(data)
+	}
+
+	if out == nil || len(out) == len(data) {
+		return nil, false
+	}
+
+	return out, true
+}
+
+// stripJPEG drops the APP1 and APP3-APP13 segments (EXIF, XMP, IPTC,
+// Photoshop), APP15 and comments. APP0 (JFIF), APP2 (ICC profiles) and
+// APP14 (Adobe, which tells how to read the colors) are kept.
+func stripJPEG(b []byte) []byte {
+	if len(b) < 4 || b[0] != 0xff || b[1] != 0xd8 {
+		return nil
+	}
+
+	out := append(make([]byte, 0, len(b)), b[:2]...)
+	i := 2
+	for i+4 <= len(b) {
+		if b[i] != 0xff {
+			return nil
+		}
+
+		marker := b[i+1]
+		if marker == 0xda { // start of scan: the rest is image data
+			return append(out, b[i:]...)
+		}
+		if marker == 0xff { // fill byte
+			i++
+			continue
+		}
+
+		size := int(binary.BigEndian.Uint16(b[i+2:]))
+		end := i + 2 + size
+		if size < 2 || end > len(b) {
+			return nil
+		}
+
+		drop := marker == 0xe1 || (marker >= 0xe3 && marker <= 0xed) || marker == 0xef || marker == 0xfe
+		if !drop {
+			out = append(out, b[i:end]...)
+		}
+		i = end
+	}
+
+	return nil
+}
+
+// pngMetadata are the PNG chunks that hold text, EXIF and timestamps.
+var pngMetadata = map[string]bool{"tEXt": true, "zTXt": true, "iTXt": true, "eXIf": true, "tIME": true}
+
+func stripPNG(b []byte) []byte {
+	const sig = "\x89PNG\r\n\x1a\n"
+	if !bytes.HasPrefix(b, []byte(sig)) {
+		return nil
+	}
+
+	out := append(make([]byte, 0, len(b)), sig...)
+	for i := len(sig); i+12 <= len(b); {
+		size := int(binary.BigEndian.Uint32(b[i:]))
+		end := i + 12 + size // length, type, data, CRC
+		if end > len(b) {
+			return nil
+		}
+
+		if !pngMetadata[string(b[i+4:i+8])] {
+			out = append(out, b[i:end]...)
+		}
+		if string(b[i+4:i+8]) == "IEND" {
+			return out
+		}
+		i = end
+	}
+
+	return nil
+}
+
+// stripWebP drops the EXIF and XMP chunks of an extended WebP and clears
+// their flags in the VP8X header.
+func stripWebP(b []byte) []byte {
+	if len(b) < 12 || string(b[0:4]) != "RIFF" || string(b[8:12]) != "WEBP" {
+		return nil
+	}
+
+	out := append(make([]byte, 0, len(b)), b[:12]...)
+	vp8x := -1
+	for i := 12; i+8 <= len(b); {
+		id := string(b[i : i+4])
+		size := int(binary.LittleEndian.Uint32(b[i+4:]))
+		end := i + 8 + size + size%2 // chunks are padded to even sizes
+		if end > len(b) {
+			return nil
+		}
+
+		switch id {
+		case "EXIF", "XMP ":
+		case "VP8X":
+			vp8x = len(out)
+			out = append(out, b[i:end]...)
+		default:
+			out = append(out, b[i:end]...)
+		}
+		i = end
+	}
+
+	if vp8x >= 0 && vp8x+9 <= len(out) {
+		out[vp8x+8] &^= 0x08 | 0x04 // EXIF and XMP flags
+	}
+	binary.LittleEndian.PutUint32(out[4:], uint32(len(out)-8))
+
+	return out
+}
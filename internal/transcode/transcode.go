@@ -0,0 +1,211 @@
+package transcode
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/brogergvhs/mangad/internal/imgfmt"
+	_ "golang.org/x/image/webp" // register the WebP decoder
+)
+
+// DefaultQuality is the JPEG quality used when none is configured.
+const DefaultQuality = 85
+
+// OriginalsFolder is where the untouched pages are moved when they are kept.
+const OriginalsFolder = "originals"
+
+type Options struct {
+	WebP          string // convert WebP pages to "jpeg" or "png"; empty keeps them
+	PNG           string // convert PNG pages to "jpeg"; empty keeps them
+	Quality       int    // JPEG quality, 1-100 (0: DefaultQuality)
+	StripMetadata bool   // drop EXIF, XMP and text chunks from JPEG, PNG and WebP pages
+}
+
+// NewOptions checks the conversion targets and fills in the default
+// quality.
+func NewOptions(webp, png string, quality int, strip bool) (Options, error) {
+	o := Options{StripMetadata: strip, Quality: quality}
+
+	var err error
+	if o.WebP, err = target(webp, "webp", "jpeg", "png"); err != nil {
+		return Options{}, err
+	}
+	if o.PNG, err = target(png, "png", "jpeg"); err != nil {
+		return Options{}, err
+	}
+
+	if o.Quality == 0 {
+		o.Quality = DefaultQuality
+	}
+	if o.Quality < 1 || o.Quality > 100 {
+		return Options{}, fmt.Errorf("invalid JPEG quality %d (use 1-100)", quality)
+	}
+
+	return o, nil
+}
+
+func target(s, from string, allowed ...string) (string, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	switch s {
+	case "", "keep", from:
+		return "", nil
+	case "jpg":
+		s = "jpeg"
+	}
+
+	for _, a := range allowed {
+		if s == a {
+			return s, nil
+		}
+	}
+
+	return "", fmt.Errorf("cannot convert %s pages to %q (use %s)", from, s, strings.Join(allowed, " or "))
+}
+
+// Enabled reports whether any page would be touched.
+func (o Options) Enabled() bool {
+	return o.WebP != "" || o.PNG != "" || o.StripMetadata
+}
+
+// Pages processes the downloaded pages in place and returns the files to
+// archive, in the same order. With keep set, the untouched pages are moved
+// to the originals folder next to them instead of being removed. Pages that
+// fail to convert are archived as they were; their errors are returned
+// joined.
+func Pages(files []string, o Options, keep bool) ([]string, error) {
+	out := make([]string, len(files))
+	errs := make([]error, len(files))
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(runtime.GOMAXPROCS(0), len(files)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				out[i], errs[i] = page(files[i], o, keep)
+				if errs[i] != nil {
+					out[i] = files[i]
+					errs[i] = fmt.Errorf("%s: %w", filepath.Base(files[i]), errs[i])
+				}
+			}
+		}()
+	}
+	for i := range files {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return out, errors.Join(errs...)
+}
+
+func page(file string, o Options, keep bool) (string, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+
+	f, ok := imgfmt.Sniff(data)
+	if !ok {
+		return file, nil
+	}
+
+	var to string
+	switch f.Name {
+	case "webp":
+		to = o.WebP
+	case "png":
+		to = o.PNG
+	}
+
+	var converted []byte
+	switch {
+	case to != "":
+		if converted, err = convert(data, to, o.Quality); err != nil {
+			return "", err
+		}
+	case o.StripMetadata:
+		converted, ok = strip(f.Name, data)
+		if !ok {
+			return file, nil
+		}
+	default:
+		return file, nil
+	}
+
+	out := file
+	if to != "" {
+		ext, _ := imgfmt.ByExt(to)
+		out = strings.TrimSuffix(file, filepath.Ext(file)) + ext.Ext()
+	}
+
+	if err := saveOriginal(file, keep); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(out, converted, 0644); err != nil {
+		return "", err
+	}
+
+	return out, nil
+}
+
+// saveOriginal moves file to the originals folder, or removes it.
+func saveOriginal(file string, keep bool) error {
+	if !keep {
+		return os.Remove(file)
+	}
+
+	dir := filepath.Join(filepath.Dir(file), OriginalsFolder)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	return os.Rename(file, filepath.Join(dir, filepath.Base(file)))
+}
+
+func convert(data []byte, to string, quality int) ([]byte, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode: %w", err)
+	}
+
+	var buf bytes.Buffer
+	switch to {
+	case "png":
+		err = png.Encode(&buf, img)
+	default:
+		err = jpeg.Encode(&buf, flatten(img), &jpeg.Options{Quality: quality})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// flatten draws images with transparency onto white, since JPEG has no
+// alpha channel and transparent areas would otherwise turn black.
+func flatten(img image.Image) image.Image {
+	if o, ok := img.(interface{ Opaque() bool }); ok && o.Opaque() {
+		return img
+	}
+
+	b := img.Bounds()
+	dst := image.NewRGBA(b)
+	draw.Draw(dst, b, image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(dst, b, img, b.Min, draw.Over)
+
+	return dst
+}
@@ -0,0 +1,112 @@
+package ui
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Event types written by EventSink.
+const (
+	EventChaptersFetched = "chapters_fetched"
+	EventChapterStarted  = "chapter_started"
+	EventImageDone       = "image_done"
+	EventChapterFailed   = "chapter_failed"
+	EventCBZWritten      = "cbz_written"
+	EventSummary         = "summary"
+)
+
+// Event is one line of the NDJSON event stream. Only the fields that make
+// sense for the event type are set.
+type Event struct {
+	Time    time.Time `json:"time"`
+	Type    string    `json:"type"`
+	Series  string    `json:"series,omitempty"` // series page URL
+	Chapter string    `json:"chapter,omitempty"`
+	URL     string    `json:"url,omitempty"`
+
+	Count  int    `json:"count,omitempty"`  // chapters fetched or selected
+	Done   int    `json:"done,omitempty"`   // images finished so far
+	Total  int    `json:"total,omitempty"`  // images in the chapter
+	Bytes  int64  `json:"bytes,omitempty"`  // bytes downloaded or CBZ size
+	Path   string `json:"path,omitempty"`   // CBZ path
+	Reason string `json:"reason,omitempty"` // failure reason
+}
+
+// summaryEvent is the last event of a run. Its counters are always
+// written, so scripts can tell a zero from a missing field.
+type summaryEvent struct {
+	Time     time.Time `json:"time"`
+	Type     string    `json:"type"`
+	Chapters int64     `json:"chapters"` // chapters written
+	Images   int64     `json:"images"`   // images downloaded
+	Bytes    int64     `json:"bytes"`    // bytes downloaded
+	Failed   int64     `json:"failed"`   // chapters failed
+	Elapsed  float64   `json:"elapsed"`  // seconds
+}
+
+// EventSink writes events as newline-delimited JSON. A nil sink discards
+// everything, so callers don't need to check whether events are enabled.
+type EventSink struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+	c   io.Closer
+}
+
+// NewEventSink writes to path, or to w when path is empty or "-".
+func NewEventSink(path string, w io.Writer) (*EventSink, error) {
+	if path == "" || path == "-" {
+		return &EventSink{enc: json.NewEncoder(w)}, nil
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	return &EventSink{enc: json.NewEncoder(f), c: f}, nil
+}
+
+func (s *EventSink) Emit(e Event) {
+	if s == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	s.encode(e)
+}
+
+// Summary emits the run summary from st.
+func (s *EventSink) Summary(st *Stats, elapsed time.Duration) {
+	if s == nil {
+		return
+	}
+
+	s.encode(summaryEvent{
+		Time:     time.Now(),
+		Type:     EventSummary,
+		Chapters: st.TotalChapters.Load(),
+		Images:   st.TotalImages.Load(),
+		Bytes:    st.TotalBytes.Load(),
+		Failed:   st.Failed.Load(),
+		Elapsed:  elapsed.Seconds(),
+	})
+}
+
+func (s *EventSink) encode(v any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_ = s.enc.Encode(v)
+}
+
+func (s *EventSink) Close() error {
+	if s == nil || s.c == nil {
+		return nil
+	}
+
+	return s.c.Close()
+}
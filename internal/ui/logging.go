@@ -2,26 +2,211 @@ package ui
 
 import (
 	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
 )
 
+type Level int
+
+const (
+	LevelTrace Level = iota
+	LevelDebug
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+var levelNames = []string{"TRACE", "DEBUG", "INFO", "WARN", "ERROR"}
+
+func (l Level) String() string {
+	if l < LevelTrace || l > LevelError {
+		return "UNKNOWN"
+	}
+
+	return levelNames[l]
+}
+
+// ParseLevel accepts trace, debug, info, warn (or warning) and error.
+func ParseLevel(s string) (Level, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if s == "WARNING" {
+		s = "WARN"
+	}
+	for i, name := range levelNames {
+		if s == name {
+			return Level(i), nil
+		}
+	}
+
+	return LevelInfo, fmt.Errorf("unknown log level %q (use trace, debug, info, warn or error)", s)
+}
+
+type LoggerOptions struct {
+	Level Level // console level
+	Quiet bool  // console shows warnings and errors only
+	Plain bool  // console lines carry a timestamp (for non-TTY output)
+
+	File       string // log file, written at debug level (or trace) regardless of Level
+	MaxSize    int64  // rotate the log file once it grows past this many bytes (0: 10 MB)
+	MaxBackups int    // rotated files kept as File.1 ... File.N (0: 3)
+}
+
+// Logger writes leveled lines with optional key/value fields to the console
+// and, optionally, to a rotating log file. Console lines are printed above
+// the progress bars while they are shown.
 type Logger struct {
-	Debug bool
+	core   *logCore
+	fields []any
+}
+
+type logCore struct {
+	mu        sync.Mutex
+	console   Level
+	fileLevel Level
+	plain     bool
+	file      io.WriteCloser
 }
 
+// NewLogger returns a console-only logger at info level, or debug level
+// when debug is set.
 func NewLogger(debug bool) *Logger {
-	return &Logger{Debug: debug}
+	level := LevelInfo
+	if debug {
+		level = LevelDebug
+	}
+
+	l, _ := NewLoggerWith(LoggerOptions{Level: level})
+	return l
+}
+
+func NewLoggerWith(opts LoggerOptions) (*Logger, error) {
+	core := &logCore{
+		console:   opts.Level,
+		fileLevel: min(opts.Level, LevelDebug),
+		plain:     opts.Plain,
+	}
+	if opts.Quiet {
+		core.console = max(core.console, LevelWarn)
+	}
+
+	if opts.File != "" {
+		f, err := openRotatingFile(opts.File, opts.MaxSize, opts.MaxBackups)
+		if err != nil {
+			return nil, fmt.Errorf("cannot open log file: %w", err)
+		}
+		core.file = f
+	}
+
+	return &Logger{core: core}, nil
+}
+
+// With returns a logger that adds the given key/value pairs to every line.
+func (l *Logger) With(kv ...any) *Logger {
+	fields := make([]any, 0, len(l.fields)+len(kv))
+	fields = append(fields, l.fields...)
+	fields = append(fields, kv...)
+
+	return &Logger{core: l.core, fields: fields}
+}
+
+// Enabled reports whether lines at level are written anywhere.
+func (l *Logger) Enabled(level Level) bool {
+	if level >= l.core.console {
+		return true
+	}
+
+	return l.core.file != nil && level >= l.core.fileLevel
+}
+
+// DebugEnabled reports whether debug lines are written anywhere.
+func (l *Logger) DebugEnabled() bool {
+	return l.Enabled(LevelDebug)
+}
+
+func (l *Logger) Close() error {
+	if l.core.file == nil {
+		return nil
+	}
+
+	return l.core.file.Close()
+}
+
+func (l *Logger) Trace(msg string, kv ...any) { l.log(LevelTrace, msg, kv) }
+func (l *Logger) Debug(msg string, kv ...any) { l.log(LevelDebug, msg, kv) }
+func (l *Logger) Info(msg string, kv ...any)  { l.log(LevelInfo, msg, kv) }
+func (l *Logger) Warn(msg string, kv ...any)  { l.log(LevelWarn, msg, kv) }
+func (l *Logger) Error(msg string, kv ...any) { l.log(LevelError, msg, kv) }
+
+func (l *Logger) Tracef(format string, args ...any) { l.logf(LevelTrace, format, args) }
+func (l *Logger) Debugf(format string, args ...any) { l.logf(LevelDebug, format, args) }
+func (l *Logger) Infof(format string, args ...any)  { l.logf(LevelInfo, format, args) }
+func (l *Logger) Warnf(format string, args ...any)  { l.logf(LevelWarn, format, args) }
+func (l *Logger) Errorf(format string, args ...any) { l.logf(LevelError, format, args) }
+
+func (l *Logger) logf(level Level, format string, args []any) {
+	if !l.Enabled(level) {
+		return
+	}
+
+	l.log(level, fmt.Sprintf(format, args...), nil)
 }
 
-func (l *Logger) Debugf(format string, args ...any) {
-	if l.Debug {
-		fmt.Printf("[DEBUG] "+format, args...)
+func (l *Logger) log(level Level, msg string, kv []any) {
+	if !l.Enabled(level) {
+		return
+	}
+
+	msg = strings.TrimRight(msg, "\n")
+	fields := formatFields(append(append([]any{}, l.fields...), kv...))
+	now := time.Now()
+
+	c := l.core
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if level >= c.console {
+		var line string
+		if c.plain {
+			line = fmt.Sprintf("%s [%s] %s%s\n", now.Format("15:04:05"), level, msg, fields)
+		} else {
+			line = fmt.Sprintf("[%s] %s%s\n", level, msg, fields)
+		}
+		writeConsole(line, level >= LevelWarn)
+	}
+
+	if c.file != nil && level >= c.fileLevel {
+		_, _ = fmt.Fprintf(c.file, "%s %-5s %s%s\n", now.Format(time.RFC3339), level, msg, fields)
 	}
 }
 
-func (l *Logger) Infof(format string, args ...any) {
-	fmt.Printf("[INFO] "+format, args...)
+// formatFields renders key/value pairs as " key=value"; values with spaces
+// are quoted.
+func formatFields(kv []any) string {
+	if len(kv) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(kv); i += 2 {
+		key := fmt.Sprint(kv[i])
+		val := "(missing)"
+		if i+1 < len(kv) {
+			val = fmt.Sprint(kv[i+1])
+		}
+		if val == "" || strings.ContainsAny(val, " \t\"=") {
+			val = fmt.Sprintf("%q", val)
+		}
+		b.WriteString(" " + key + "=" + val)
+	}
+
+	return b.String()
 }
 
-func (l *Logger) Errorf(format string, args ...any) {
-	fmt.Printf("[ERROR] "+format, args...)
+// IsTerminal reports whether f is an interactive terminal.
+func IsTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
 }
@@ -2,6 +2,7 @@ package ui
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"sync/atomic"
 	"time"
@@ -13,22 +14,82 @@ import (
 )
 
 type MPBProgressManager struct {
-	p *mpb.Progress
+	p     *mpb.Progress
+	plain bool
+}
+
+var (
+	// activePM is the manager whose bars are on screen; console log lines
+	// are printed through it so they end up above the bars.
+	activePM  atomic.Pointer[MPBProgressManager]
+	plainMode atomic.Bool
+)
+
+// SetPlain turns off progress bars: every finished chapter prints one line
+// instead. Plain mode is also used when stdout is not a terminal at the
+// time of the call, so call it before stdout is redirected.
+func SetPlain(plain bool) {
+	plainMode.Store(plain || !IsTerminal(os.Stdout))
+}
+
+// Plain reports whether progress bars are turned off.
+func Plain() bool {
+	return plainMode.Load()
 }
 
 func NewProgressManager(_ int) *MPBProgressManager {
+	plain := Plain()
+	out := io.Writer(os.Stdout)
+	if plain {
+		out = nil
+	}
+
 	p := mpb.New(
 		mpb.WithWidth(52),
-		mpb.WithOutput(os.Stdout),
+		mpb.WithOutput(out),
 		mpb.WithRefreshRate(120*time.Millisecond),
 	)
-	return &MPBProgressManager{p: p}
+	pm := &MPBProgressManager{p: p, plain: plain}
+	if !plain {
+		activePM.Store(pm)
+	}
+
+	return pm
 }
 
 func (pm *MPBProgressManager) Close() {
+	activePM.CompareAndSwap(pm, nil)
 	pm.p.Wait()
 }
 
+// writeConsole prints a log line above the active progress bars, or
+// straight to stdout (stderr when toStderr is set) when none are shown.
+func writeConsole(line string, toStderr bool) {
+	if pm := activePM.Load(); pm != nil {
+		if _, err := pm.p.Write([]byte(line)); err == nil {
+			return
+		}
+	}
+
+	out := os.Stdout
+	if toStderr {
+		out = os.Stderr
+	}
+	_, _ = io.WriteString(out, line)
+}
+
+// Printf prints a console message above the active progress bars, so a
+// queue entry that starts while another one downloads doesn't tear its
+// bars. Without bars it goes straight to stdout.
+func Printf(format string, args ...any) {
+	writeConsole(fmt.Sprintf(format, args...), false)
+}
+
+// Println is Printf with fmt.Sprintln formatting.
+func Println(args ...any) {
+	writeConsole(fmt.Sprintln(args...), false)
+}
+
 func (pm *MPBProgressManager) Register(prefix string) *ProgressHandle {
 	h := &ProgressHandle{
 		pm:     pm,
@@ -50,6 +111,20 @@ type ProgressHandle struct {
 	elapsed atomic.Int64
 
 	final atomic.Bool
+
+	events   *EventSink
+	event    Event
+	lastDone atomic.Int64
+}
+
+// WithEvents makes the handle emit an image_done event (based on ev) every
+// time another image finishes.
+func (h *ProgressHandle) WithEvents(sink *EventSink, ev Event) *ProgressHandle {
+	h.events = sink
+	h.event = ev
+	h.event.Type = EventImageDone
+
+	return h
 }
 
 func (h *ProgressHandle) initBar() {
@@ -104,6 +179,12 @@ func (h *ProgressHandle) Update(done, total int, bytes int64) {
 
 	atomic.StoreInt64(&h.bytes, bytes)
 	h.bar.SetCurrent(int64(done))
+
+	if h.events != nil && int64(done) > h.lastDone.Swap(int64(done)) {
+		ev := h.event
+		ev.Done, ev.Total, ev.Bytes = done, int(atomic.LoadInt64(&h.total)), bytes
+		h.events.Emit(ev)
+	}
 }
 
 func (h *ProgressHandle) MarkDone() {
@@ -114,6 +195,10 @@ func (h *ProgressHandle) MarkDone() {
 	elapsedSec := int64(time.Since(h.start).Seconds())
 
 	h.elapsed.Store(elapsedSec)
+	if h.pm.plain {
+		fmt.Printf("%s  %d/%d pages | %s | %ds\n",
+			h.prefix, h.bar.Current(), atomic.LoadInt64(&h.total), util.Human(atomic.LoadInt64(&h.bytes)), elapsedSec)
+	}
 	h.bar.SetCurrent(h.total)
 	h.bar.SetTotal(h.total, true)
 }
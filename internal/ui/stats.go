@@ -6,4 +6,5 @@ type Stats struct {
 	TotalImages   atomic.Int64
 	TotalBytes    atomic.Int64
 	TotalChapters atomic.Int64
+	Failed        atomic.Int64 // chapters that could not be downloaded
 }
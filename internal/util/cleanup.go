@@ -4,44 +4,74 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
-	"path/filepath"
-	"strings"
+	"slices"
+	"sync"
 	"syscall"
 )
 
+var (
+	interruptOnce sync.Once
+	interruptMu   sync.Mutex
+	interruptDirs []string
+	tempFolders   = map[string]struct{}{}
+)
+
+// SetupInterruptHandler removes the temp folders that are being written when
+// the process is interrupted, and outputDir if that leaves it empty. It can
+// be called once per output folder; a single handler cleans all of them.
 func SetupInterruptHandler(outputDir string) {
-	sig := make(chan os.Signal, 1)
-	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	interruptMu.Lock()
+	if !slices.Contains(interruptDirs, outputDir) {
+		interruptDirs = append(interruptDirs, outputDir)
+	}
+	interruptMu.Unlock()
 
-	go func() {
-		<-sig
-		fmt.Println("\nInterrupt received. Cleaning up...")
+	interruptOnce.Do(func() {
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
 
-		CleanupUnfinishedTempFolders(outputDir)
-		RemoveIfEmpty(outputDir)
-		fmt.Println("\nExiting due to interrupt.")
+		go func() {
+			<-sig
+			fmt.Println("\nInterrupt received. Cleaning up...")
 
-		os.Exit(1)
-	}()
+			interruptMu.Lock()
+			removeTempFolders()
+			for _, dir := range interruptDirs {
+				RemoveIfEmpty(dir)
+			}
+			interruptMu.Unlock()
+			fmt.Println("\nExiting due to interrupt.")
+
+			os.Exit(1)
+		}()
+	})
 }
 
-func CleanupUnfinishedTempFolders(outputDir string) {
-	entries, err := os.ReadDir(outputDir)
-	if err != nil {
-		return
-	}
+// TrackTempFolder registers a chapter's temp folder while its pages are
+// downloaded and archived. Call the returned func once the CBZ is written
+// (or the chapter failed), so a kept folder survives an interrupt.
+func TrackTempFolder(dir string) func() {
+	interruptMu.Lock()
+	tempFolders[dir] = struct{}{}
+	interruptMu.Unlock()
 
-	for _, e := range entries {
-		name := e.Name()
-		if e.IsDir() && strings.HasSuffix(name, "_tmp") {
-			full := filepath.Join(outputDir, name)
+	return func() {
+		interruptMu.Lock()
+		delete(tempFolders, dir)
+		interruptMu.Unlock()
+	}
+}
 
-			if err := os.RemoveAll(full); err != nil {
-				fmt.Printf("Error cleaning up %s: %v\n", full, err)
-			} else {
-				fmt.Printf("Removed %s\n", full)
-			}
+// removeTempFolders removes the temp folders registered with
+// TrackTempFolder. The caller holds interruptMu.
+func removeTempFolders() {
+	for dir := range tempFolders {
+		if err := os.RemoveAll(dir); err != nil {
+			fmt.Printf("Error cleaning up %s: %v\n", dir, err)
+		} else {
+			fmt.Printf("Removed %s\n", dir)
 		}
+		delete(tempFolders, dir)
 	}
 }
 
@@ -7,10 +7,12 @@ import (
 	"log"
 	"os"
 	"path/filepath"
-	"sort"
+	"time"
 )
 
-func CreateCBZ(files []string, output string) error {
+// CreateCBZ writes files to the archive in the given order, which is the
+// reading order, followed by comicInfo as ComicInfo.xml when it is set.
+func CreateCBZ(files []string, comicInfo []byte, output string) error {
 	out, err := os.Create(output)
 	if err != nil {
 		return fmt.Errorf("cbz: %w", err)
@@ -29,13 +31,22 @@ func CreateCBZ(files []string, output string) error {
 		}
 	}()
 
-	sort.Strings(files)
 	for _, file := range files {
 		if err := addFileToZip(z, file); err != nil {
 			return err
 		}
 	}
 
+	if comicInfo != nil {
+		w, err := z.CreateHeader(&zip.FileHeader{Name: "ComicInfo.xml", Method: zip.Deflate, Modified: time.Now()})
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(comicInfo); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -7,6 +7,7 @@ import (
 	"net/http/cookiejar"
 	"os"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -16,9 +17,14 @@ type HTTPClientOptions struct {
 	Cookie      string
 	CookieFile  string
 	Transport   http.RoundTripper
-	DebugLogger interface {
-		Debugf(string, ...any)
-	}
+	DebugLogger httpLogger
+}
+
+// httpLogger logs client setup at debug level and every request at trace
+// level.
+type httpLogger interface {
+	Debugf(string, ...any)
+	Tracef(string, ...any)
 }
 
 func NewHTTPClient(opts HTTPClientOptions) (*http.Client, error) {
@@ -40,11 +46,12 @@ func NewHTTPClient(opts HTTPClientOptions) (*http.Client, error) {
 
 	client := &http.Client{
 		Timeout: opts.Timeout,
-		Transport: roundTripper{
+		Transport: &roundTripper{
 			base:         baseTransport,
 			ua:           opts.UserAgent,
 			cookieHeader: joinCookies(opts.Cookie, opts.CookieFile),
 			log:          opts.DebugLogger,
+			hostUA:       map[string]string{},
 		},
 		Jar: jar,
 	}
@@ -61,27 +68,71 @@ type roundTripper struct {
 	base         http.RoundTripper
 	ua           string
 	cookieHeader string
-	log          interface{ Debugf(string, ...any) }
+	log          httpLogger
+
+	mu     sync.RWMutex
+	hostUA map[string]string
 }
 
-func (rt roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
-	if rt.ua != "" {
-		req.Header.Set("User-Agent", rt.ua)
+func (rt *roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if ua := rt.userAgentFor(req.URL.Hostname()); ua != "" {
+		req.Header.Set("User-Agent", ua)
 	}
 
 	if rt.cookieHeader != "" {
-		if req.Header.Get("Cookie") == "" {
+		// the jar may already have set cookies (e.g. clearance cookies
+		// handed over from the browser fallback), keep both
+		if existing := req.Header.Get("Cookie"); existing == "" {
 			req.Header.Set("Cookie", rt.cookieHeader)
+		} else if !strings.Contains(existing, rt.cookieHeader) {
+			req.Header.Set("Cookie", existing+"; "+rt.cookieHeader)
 		}
 	}
 
 	if rt.log != nil {
-		rt.log.Debugf("HTTP %s %s", req.Method, req.URL.String())
+		rt.log.Tracef("HTTP %s %s", req.Method, req.URL.String())
 	}
 
 	return rt.base.RoundTrip(req)
 }
 
+// userAgentFor returns the UA registered for host or one of its parent
+// domains, falling back to the client-wide UA.
+func (rt *roundTripper) userAgentFor(host string) string {
+	rt.mu.RLock()
+	defer rt.mu.RUnlock()
+
+	for h := host; h != ""; {
+		if ua, ok := rt.hostUA[h]; ok {
+			return ua
+		}
+
+		_, rest, found := strings.Cut(h, ".")
+		if !found || !strings.Contains(rest, ".") {
+			break
+		}
+		h = rest
+	}
+
+	return rt.ua
+}
+
+// SetHostUserAgent makes every request to host (and its subdomains) use ua.
+// Cloudflare clearance cookies are bound to the browser's User-Agent, so
+// they only work when the HTTP client sends the same one.
+func SetHostUserAgent(c *http.Client, host, ua string) bool {
+	rt, ok := c.Transport.(*roundTripper)
+	if !ok || host == "" || ua == "" {
+		return false
+	}
+
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	rt.hostUA[strings.TrimPrefix(host, ".")] = ua
+
+	return true
+}
+
 func joinCookies(inline, file string) string {
 	s := strings.TrimSpace(inline)
 	if file != "" {
@@ -106,6 +157,16 @@ func joinCookies(inline, file string) string {
 }
 
 // DoWithRetry executes request with simple retry policy.
+// HTTPError is a response with an unexpected status code.
+type HTTPError struct {
+	Status int
+	URL    string
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("HTTP %d", e.Status)
+}
+
 func DoWithRetry(c *http.Client, req *http.Request, attempts int, backoff time.Duration) (*http.Response, error) {
 	var resp *http.Response
 	var err error
@@ -124,7 +185,7 @@ func DoWithRetry(c *http.Client, req *http.Request, attempts int, backoff time.D
 	}
 
 	if err == nil && resp != nil {
-		return resp, fmt.Errorf("HTTP %d after %d attempts", resp.StatusCode, attempts)
+		return resp, fmt.Errorf("%w after %d attempts", &HTTPError{Status: resp.StatusCode, URL: req.URL.String()}, attempts)
 	}
 
 	return nil, err